@@ -1,7 +1,10 @@
 package main
 
 import (
+	"database/sql"
 	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"github.com/pingcap/go-randgen/compare"
 	"github.com/pingcap/go-randgen/gendata"
 	"github.com/pingcap/go-randgen/grammar"
 	"github.com/pingcap/go-randgen/grammar/sql_generator"
@@ -26,6 +29,11 @@ var skipZz bool
 var seed int64
 var outPath string
 
+var batchSize int
+var loadThreads int
+var dataFormat string
+var dataDir string
+
 // driver name
 var dbms string
 
@@ -53,6 +61,15 @@ func initCmd() {
 	rootCmd.PersistentFlags().StringVarP(&outPath, "output", "O", "output",
 		"sql output file path")
 
+	rootCmd.PersistentFlags().IntVar(&batchSize, "batch", 0,
+		"max rows in one insert statement generated by zz, all rows of a table in one statement if it is <= 0")
+	rootCmd.PersistentFlags().IntVar(&loadThreads, "load-threads", 1,
+		"number of tables loaded concurrently in one dsn")
+	rootCmd.PersistentFlags().StringVar(&dataFormat, "data-format", "sql",
+		"format of data generated by zz, sql means insert statements, csv or tsv means data files loaded by `LOAD DATA LOCAL INFILE`")
+	rootCmd.PersistentFlags().StringVar(&dataDir, "data-dir", "",
+		"directory of csv or tsv data files, default is <output>_data for gentest and a temporary directory for others")
+
 	// driver
 	rootCmd.PersistentFlags().StringVarP(&dbms, "dbms", "D", "mysql",
 		"specify the DBMS driver, defult MySQL. Supported: mysql, sqlite3.")
@@ -62,6 +79,7 @@ func initCmd() {
 	rootCmd.AddCommand(newGenDataCmd())
 	rootCmd.AddCommand(newGensqlCmd())
 	rootCmd.AddCommand(newZzCmd())
+	rootCmd.AddCommand(newGeneratorsCmd())
 	rootCmd.AddCommand(newListenCmd())
 }
 
@@ -73,7 +91,15 @@ func main() {
 	}
 }
 
-func getDdls() ([]string, gendata.Keyfun) {
+var dataFormats = map[string]*gendata.FileFormat{
+	"csv": gendata.CsvFormat,
+	"tsv": gendata.TsvFormat,
+}
+
+// get ddls and data of tables, if data format is csv or tsv, data files
+// will be written into data dir (defaultDataDir if it is not specified) and
+// inserts are replaced by `LOAD DATA` statements
+func getDdls(defaultDataDir string) ([]*gendata.TableSqls, gendata.Keyfun) {
 	var zzBs []byte
 	var err error
 	if zzPath != "" {
@@ -88,12 +114,94 @@ func getDdls() ([]string, gendata.Keyfun) {
 
 	zz := string(zzBs)
 
-	ddls, keyf, err := gendata.ByZz(zz)
+	if dataFormat == "sql" {
+		tables, keyf, err := gendata.TablesByZz(zz, batchSize)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		return tables, keyf
+	}
+
+	format, ok := dataFormats[dataFormat]
+	if !ok {
+		log.Fatalf("Fatal Error: unknown data format %s\n", dataFormat)
+	}
+
+	dir := dataDir
+	if dir == "" {
+		dir = defaultDataDir
+	}
+	files, keyf, err := gendata.FilesByZz(zz, dir, format)
 	if err != nil {
 		log.Fatalln(err)
 	}
+	log.Printf("write data files in %s\n", dir)
+
+	tables := make([]*gendata.TableSqls, 0, len(files))
+	for _, file := range files {
+		mysql.RegisterLocalFile(file.Path)
+		tables = append(tables, &gendata.TableSqls{
+			Name:    file.Name,
+			Ddl:     file.Ddl,
+			Inserts: []string{file.LoadSql()},
+		})
+	}
+
+	return tables, keyf
+}
+
+// temporary directory for data files which are only used by load
+func tempDataDir() string {
+	if dataFormat == "sql" || dataDir != "" {
+		return ""
+	}
+	dir, err := ioutil.TempDir("", "randgen_data")
+	if err != nil {
+		log.Fatalf("Fatal Error: create data directory fail %v\n", err)
+	}
+	return dir
+}
 
-	return ddls, keyf
+// remove the data files in dir created by tempDataDir after they are loaded
+func removeTempDataDir(dir string) {
+	if dir == "" {
+		return
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		log.Printf("Error: remove data directory %s fail %v\n", dir, err)
+	}
+}
+
+func flatDdls(tables []*gendata.TableSqls) []string {
+	ddls := make([]string, 0)
+	for _, table := range tables {
+		ddls = append(ddls, table.Sqls()...)
+	}
+	return ddls
+}
+
+// load ddls and data into dbs, tables are loaded concurrently
+func loadDdls(tables []*gendata.TableSqls, dbs ...*sql.DB) {
+	groups := make([][]string, 0, len(tables))
+	for _, table := range tables {
+		groups = append(groups, table.Sqls())
+	}
+
+	lastPercent := 0
+	progress := func(done int, total int) {
+		percent := done * 100 / total
+		if percent/10 > lastPercent/10 {
+			log.Printf("loading data %d%% (%d/%d statements)\n", percent, done, total)
+		}
+		lastPercent = percent
+	}
+
+	// ddls must exec without error
+	errSql, err := compare.ExecSqlGroupsInDbs(groups, loadThreads, progress, dbs...)
+	if err != nil {
+		log.Printf("Fatal Error: data prepare ddl exec error %v\n", err)
+		log.Fatalln(errSql)
+	}
 }
 
 func loadYy() string {
@@ -128,7 +236,7 @@ func getRandSqls(keyf gendata.Keyfun) []string {
 func getIter(keyf gendata.Keyfun) sql_generator.SQLIterator {
 	yy := loadYy()
 
-	iterator, err := grammar.NewIterWithRander(yy, root, maxRecursive, keyf,
+	iterator, err := grammar.NewIterWithRand(yy, root, maxRecursive, sql_generator.KeyFuncs(keyf),
 		rand.New(rand.NewSource(seed)), debug)
 	if err != nil {
 		log.Fatalln("Fatal Error: " + err.Error())
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"database/sql"
 	"errors"
 	"fmt"
 	"github.com/fatih/color"
@@ -15,12 +16,28 @@ import (
 	"math"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 )
 
 var dsn1 string
 var dsn2 string
+var dsns []string
 var order bool
+var orderBy bool
 var dumpDir string
+var tolerance = compare.NewTolerance()
+var compareSchema bool
+var errCodesPath string
+var errCodes *compare.ErrCodes
+var compareWarnings bool
+var ignoreWarnings []uint
+var stmtTimeout time.Duration
+var planMode bool
+var explainStmt string
+var perfMode bool
+var perf = &compare.Perf{}
+var perfDumpDir string
 
 func newExecCmd() *cobra.Command {
 	execCmd := &cobra.Command{
@@ -30,14 +47,46 @@ func newExecCmd() *cobra.Command {
 			if yyPath == "" {
 				return errors.New("yy are required")
 			}
-			if dsn1 == "" || dsn2 == "" {
+			if len(dsns) > 0 {
+				if dsn1 != "" || dsn2 != "" {
+					return errors.New("--dsn can not be used with --dsn1 and --dsn2")
+				}
+				if len(dsns) < 2 {
+					return errors.New("--dsn must be given at least twice")
+				}
+			} else if dsn1 == "" || dsn2 == "" {
 				return errors.New("dsn must have a pair")
 			}
 
+			if order && orderBy {
+				return errors.New("--order can not be used with --order-by")
+			}
+
+			if planMode && len(dsns) > 0 {
+				return errors.New("--plan can not be used with --dsn")
+			}
+
+			if perfMode {
+				if len(dsns) > 0 || planMode {
+					return errors.New("--perf can not be used with --dsn and --plan")
+				}
+				if perf.Ratio <= 1 {
+					return errors.New("--perf-ratio must be larger than 1")
+				}
+			}
+
 			if maxRecursive <= 0 {
 				maxRecursive = math.MaxInt32
 			}
 
+			if errCodesPath != "" {
+				var err error
+				errCodes, err = compare.LoadErrCodes(errCodesPath)
+				if err != nil {
+					return fmt.Errorf("load error codes file %s fail, %v", errCodesPath, err)
+				}
+			}
+
 			return nil
 		},
 		Run: execAction,
@@ -45,10 +94,48 @@ func newExecCmd() *cobra.Command {
 
 	execCmd.Flags().StringVar(&dsn1, "dsn1", "", "one of compare mysql dsn")
 	execCmd.Flags().StringVar(&dsn2, "dsn2", "", "another compare mysql dsn")
+	execCmd.Flags().StringArrayVar(&dsns, "dsn", nil,
+		"compare mysql dsn, given N times to compare N dsns by majority voting")
 	execCmd.Flags().BoolVar(&order, "order",
 		false, "compare sql result with order")
+	execCmd.Flags().BoolVar(&orderBy, "order-by", false,
+		"compare sql result in the order of the outermost ORDER BY, rows with tied sort keys can be in any order")
 	execCmd.Flags().StringVar(&dumpDir, "dump",
 		"dump", "inconsistent sqls dump directory")
+	execCmd.Flags().Float64Var(&tolerance.FloatRel, "float-rel", 0,
+		"floats are equal if their difference is at most float-rel times the larger absolute value")
+	execCmd.Flags().Float64Var(&tolerance.FloatAbs, "float-abs", 0,
+		"floats are equal if their difference is at most float-abs")
+	execCmd.Flags().BoolVar(&tolerance.Decimal, "decimal-norm", false,
+		"compare decimals by value, like 1.0 and 1")
+	execCmd.Flags().IntVar(&tolerance.TemporalFsp, "temporal-fsp", -1,
+		"truncate fractional seconds of temporals to the digits before comparing, negative means not truncated")
+	execCmd.Flags().BoolVar(&tolerance.CaseInsensitive, "ci", false,
+		"compare strings case insensitively")
+	execCmd.Flags().BoolVar(&compareSchema, "schema", false,
+		"compare column names, types, nullability, precision and scale of query results")
+	execCmd.Flags().StringVar(&errCodesPath, "err-codes", "",
+		"json file declaring equivalent and ignorable error codes of two dsns")
+	execCmd.Flags().BoolVar(&compareWarnings, "warnings", false,
+		"compare level, code and count of SHOW WARNINGS after every sql")
+	execCmd.Flags().UintSliceVar(&ignoreWarnings, "ignore-warnings", []uint{},
+		"warning codes skipped when comparing warnings, such as 1292,1366")
+	execCmd.Flags().DurationVar(&stmtTimeout, "timeout", 0,
+		"cancel a sql running longer than it and kill it by KILL QUERY, such as 30s, 0 means no timeout")
+	execCmd.Flags().BoolVar(&planMode, "plan", false,
+		"compare plan shapes of queries instead of results, dumps are grouped by plan shape signatures")
+	execCmd.Flags().StringVar(&explainStmt, "explain", "EXPLAIN",
+		"explain statement of --plan, such as \"EXPLAIN FORMAT='brief'\"")
+	execCmd.Flags().BoolVar(&perfMode, "perf", false,
+		"time sqls in two dsns and dump sqls whose latency ratio exceeds --perf-ratio")
+	execCmd.Flags().Float64Var(&perf.Ratio, "perf-ratio", 2,
+		"sqls whose slower latency is perf-ratio times the faster one are latency regressions")
+	execCmd.Flags().DurationVar(&perf.Slow, "perf-slow", 100*time.Millisecond,
+		"queries slower than it are repeated to reduce noise, sqls faster than it in both dsns are never regressions")
+	execCmd.Flags().IntVar(&perf.Repeat, "perf-repeat", 3,
+		"times of repeating a slow query, the median latencies are compared")
+	execCmd.Flags().StringVar(&perfDumpDir, "perf-dump",
+		"perf_dump", "latency regression sqls dump directory")
 
 	return execCmd
 }
@@ -56,6 +143,7 @@ func newExecCmd() *cobra.Command {
 type dumpInfo struct {
 	num     int // serial number
 	sql     string
+	kind    string // kind of inconsistency, see compare.Kind
 	dsn1    string
 	dsn2    string
 	dsn1Res compare.DsnRes
@@ -71,6 +159,12 @@ func (dump *dumpInfo) String() string {
 	bs.WriteString("[sql]\n\n")
 	bs.WriteString(dump.sql + "\n\n")
 
+	// [kind]
+	if dump.kind != "" {
+		bs.WriteString("[kind]\n\n")
+		bs.WriteString(dump.kind + "\n\n")
+	}
+
 	// [err]
 	bs.WriteString("[err]\n\n")
 	bs.WriteString(dsn1Tag)
@@ -91,9 +185,61 @@ func (dump *dumpInfo) String() string {
 	bs.WriteString(dsn2Tag)
 	bs.WriteString(dsn2Colored)
 
+	// [schema]
+	if res, ok := dump.dsn1Res.(*compare.QueryDsnRes); ok && len(res.SchemaDiffs) > 0 {
+		bs.WriteString("\n\n[schema]\n\n")
+		bs.WriteString(strings.Join(res.SchemaDiffs, "\n"))
+	}
+
+	// [warnings]
+	if w1, w2 := compare.WarningsOf(dump.dsn1Res), compare.WarningsOf(dump.dsn2Res); w1 != nil && w2 != nil &&
+		(len(w1.Warnings) > 0 || len(w2.Warnings) > 0 || len(w1.WarningDiffs) > 0) {
+		bs.WriteString("\n\n[warnings]\n\n")
+		bs.WriteString(dsn1Tag)
+		for _, w := range w1.Warnings {
+			bs.WriteString(w.String() + "\n\n")
+		}
+		bs.WriteString(dsn2Tag)
+		for _, w := range w2.Warnings {
+			bs.WriteString(w.String() + "\n\n")
+		}
+		bs.WriteString(strings.Join(w1.WarningDiffs, "\n"))
+	}
+
+	// [explain]
+	plan1, ok1 := dump.dsn1Res.(*compare.PlanDsnRes)
+	plan2, ok2 := dump.dsn2Res.(*compare.PlanDsnRes)
+	if ok1 && ok2 {
+		bs.WriteString("\n\n[explain]\n\n")
+		bs.WriteString(dsn1Tag)
+		bs.WriteString(plan1.Res.String() + "\n\n")
+		bs.WriteString(dsn2Tag)
+		bs.WriteString(plan2.Res.String())
+	}
+
+	// [diff]
+	if diffs := rowDiffs(dump.dsn1Res, dump.dsn2Res); len(diffs) > 0 {
+		bs.WriteString("\n\n[diff]\n\n")
+		bs.WriteString("count in dsn1 : count in dsn2 : row\n")
+		for _, diff := range diffs {
+			bs.WriteString("\n" + diff.String())
+		}
+	}
+
 	return bs.String()
 }
 
+// rows appearing different times in two query results, nil if
+// they are not both successful query results
+func rowDiffs(dsn1Res compare.DsnRes, dsn2Res compare.DsnRes) []*compare.RowDiff {
+	res1, ok1 := dsn1Res.(*compare.QueryDsnRes)
+	res2, ok2 := dsn2Res.(*compare.QueryDsnRes)
+	if !ok1 || !ok2 || res1.Err() != nil || res2.Err() != nil || res1.Res == nil || res2.Res == nil {
+		return nil
+	}
+	return res1.Res.RowDiffs(res2.Res)
+}
+
 // dump inconsistent sqls and diff info into dump dir
 func dumpVisitor(dsn1, dsn2 string) compare.Visitor {
 	count := 0
@@ -102,6 +248,7 @@ func dumpVisitor(dsn1, dsn2 string) compare.Visitor {
 		info := &dumpInfo{
 			num:     count,
 			sql:     sql,
+			kind:    compare.Kind(dsn1Res, dsn2Res),
 			dsn1:    dsn1,
 			dsn2:    dsn2,
 			dsn1Res: dsn1Res,
@@ -118,6 +265,43 @@ func dumpVisitor(dsn1, dsn2 string) compare.Visitor {
 	}
 }
 
+// dump queries whose plan shapes differ into sub directories of
+// dump dir named by the plan shape signatures in two dsns
+func planDumpVisitor(dsn1, dsn2 string) compare.Visitor {
+	counts := make(map[string]int)
+	return func(sql string, dsn1Res compare.DsnRes, dsn2Res compare.DsnRes) error {
+		group := planSignature(dsn1Res) + "_" + planSignature(dsn2Res)
+		dir := filepath.Join(dumpDir, group)
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			return err
+		}
+
+		info := &dumpInfo{
+			num:     counts[group],
+			sql:     sql,
+			dsn1:    dsn1,
+			dsn2:    dsn2,
+			dsn1Res: dsn1Res,
+			dsn2Res: dsn2Res,
+		}
+
+		err := ioutil.WriteFile(filepath.Join(dir,
+			fmt.Sprintf("%d.log", counts[group])), []byte(info.String()), os.ModePerm)
+		if err != nil {
+			return err
+		}
+		counts[group]++
+		return nil
+	}
+}
+
+func planSignature(res compare.DsnRes) string {
+	if plan, ok := res.(*compare.PlanDsnRes); ok {
+		return plan.Signature()
+	}
+	return "error"
+}
+
 const analyzeTemp = `%s : %d : %d : %s
 
 example sql:
@@ -129,35 +313,39 @@ func execAction(cmd *cobra.Command, args []string) {
 	if isDirExist(dumpDir) {
 		log.Fatalln("Fatal Error: dump directory already exist")
 	}
-
-	db1, err := compare.OpenDBWithRetry(dbms, dsn1)
-	if err != nil {
-		log.Fatalf("connect dsn1 %s error %v\n", dsn1, err)
+	if perfMode && isDirExist(perfDumpDir) {
+		log.Fatalln("Fatal Error: perf dump directory already exist")
 	}
 
-	db2, err := compare.OpenDBWithRetry(dbms, dsn2)
-	if err != nil {
-		log.Fatalf("connect dsn2 %s error %v\n", dsn2, err)
+	dsnList := dsns
+	if len(dsnList) == 0 {
+		dsnList = []string{dsn1, dsn2}
+	}
+	dbs := make([]*sql.DB, len(dsnList))
+	for i, dsn := range dsnList {
+		db, err := compare.OpenDBWithRetry(dbms, dsn)
+		if err != nil {
+			log.Fatalf("connect dsn%d %s error %v\n", i+1, dsn, err)
+		}
+		dbs[i] = db
 	}
 
-	log.Println("Open DB ok, starting generate data in two db by ddls")
+	log.Printf("Open DB ok, starting generate data in %d db by ddls\n", len(dbs))
 
 	var keyf gendata.Keyfun
+	var err error
 
 	if !skipZz {
-		var ddls []string
-		ddls, keyf = getDdls()
+		var tables []*gendata.TableSqls
+		tempDir := tempDataDir()
+		tables, keyf = getDdls(tempDir)
 
-		// ddls must exec without error
-		errSql, err := compare.ExecSqlsInDbs(ddls, db1, db2)
-		if err != nil {
-			log.Printf("Fatal Error: data prepare ddl exec error %v\n", err)
-			log.Fatalln(errSql)
-		}
+		loadDdls(tables, dbs...)
+		removeTempDataDir(tempDir)
 
 		log.Println("generating data ok")
 	} else {
-		keyf, err = gendata.ByDb(db1, dbms)
+		keyf, err = gendata.ByDb(dbs[0], dbms)
 		if err != nil {
 			log.Fatalf("Fatal Error: %v\n", err)
 		}
@@ -168,10 +356,22 @@ func execAction(cmd *cobra.Command, args []string) {
 	if err != nil {
 		log.Fatalf("Fatal Error: dump dir %s create fail %v\n", dumpDir, err)
 	}
+	if perfMode {
+		if err = os.MkdirAll(perfDumpDir, os.ModePerm); err != nil {
+			log.Fatalf("Fatal Error: perf dump dir %s create fail %v\n", perfDumpDir, err)
+		}
+	}
 
 	log.Println("starting execute sqls generated by yy")
 
 	visitor := dumpVisitor(dsn1, dsn2)
+	if planMode {
+		visitor = planDumpVisitor(dsn1, dsn2)
+	}
+	voteVisitor := voteDumpVisitor(dsnList)
+	stats := &compare.LatencyStats{}
+	perfVisitor := perfDumpVisitor(dsn1, dsn2, stats)
+	opts := compareOptions()
 
 	if queries < 0 {
 		log.Println("infinite test...")
@@ -180,19 +380,57 @@ func execAction(cmd *cobra.Command, args []string) {
 
 	sqlIter := getIter(keyf)
 	err = sqlIter.Visit(sql_generator.FixedTimesVisitor(func(_ int, sql string) {
-		consistent, dsn1Res, dsn2Res := compare.BySql(sql, db1, db2, !order)
+		if len(dsns) > 0 {
+			if vote := compare.BySqlInDbs(sql, dbs, opts); !vote.Consistent() {
+				if err := voteVisitor(vote); err != nil {
+					log.Printf("Error: dump inconsistent sql fail, %v\n", err)
+				}
+			}
+			return
+		}
+		if planMode {
+			consistent, dsn1Res, dsn2Res := compare.ByPlan(sql, dbs[0], dbs[1], explainStmt, opts)
+			if !consistent {
+				visitor(sql, dsn1Res, dsn2Res)
+			}
+			return
+		}
+		consistent, dsn1Res, dsn2Res := compare.BySqlWithOptions(sql, dbs[0], dbs[1], opts)
 		if !consistent {
 			visitor(sql, dsn1Res, dsn2Res)
 		}
+		if perfMode {
+			if latency := perf.Measure(sql, dbs[0], dbs[1], dsn1Res, dsn2Res, opts); latency != nil {
+				perfVisitor(latency)
+			}
+		}
 	}, queries))
 
 	if err != nil {
 		log.Fatalf("Fatal Error: %v \n", err)
 	}
 
+	if perfMode {
+		log.Printf("latency percentiles:\n%s\n", stats)
+	}
+
 	log.Println("dump ok")
 }
 
+// options of comparing results by flags, values are compared
+// by bytes if there is no tolerance flag
+func compareOptions() *compare.Options {
+	opts := &compare.Options{Order: order, OrderBy: orderBy, Schema: compareSchema,
+		ErrCodes: errCodes, Warnings: compareWarnings, Timeout: stmtTimeout}
+	for _, code := range ignoreWarnings {
+		opts.IgnoreWarnings = append(opts.IgnoreWarnings, uint16(code))
+	}
+	if *tolerance != *compare.NewTolerance() {
+		opts.Tolerance = tolerance
+	}
+	return opts
+}
+
 func isDirExist(path string) bool {
 	s, err := os.Stat(path)
 	if err != nil {
@@ -2,9 +2,15 @@ package main
 
 import (
 	"fmt"
+	"github.com/go-sql-driver/mysql"
 	"github.com/pingcap/go-randgen/compare"
 	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestExecErr(t *testing.T) {
@@ -88,3 +94,265 @@ select * from test
 
 	assert.Equal(t, expected, info.String())
 }
+
+func TestDumpRowDiffs(t *testing.T) {
+	mockRes2 := &compare.SqlResult{
+		Header: []string{"aaa", "bbbb"},
+		Data: [][][]byte{
+			{[]byte("haha"), []byte("baba")},
+			{[]byte("haha"), []byte("baba")},
+			{[]byte("mmmm"), nil},
+		},
+	}
+	info := &dumpInfo{
+		sql:     "select * from test",
+		dsn1:    "dsn1",
+		dsn2:    "dsn2",
+		dsn1Res: &compare.QueryDsnRes{Res: mockRes1},
+		dsn2Res: &compare.QueryDsnRes{Res: mockRes2},
+	}
+
+	assert.True(t, strings.HasSuffix(info.String(), `[diff]
+
+count in dsn1 : count in dsn2 : row
+
+1 : 2 : ("haha", "baba")
+1 : 0 : ("mmmm", "popo")
+0 : 1 : ("mmmm", NULL)`))
+}
+
+func TestCompareOptions(t *testing.T) {
+	reInitCmd()
+	defer func() {
+		*tolerance = *compare.NewTolerance()
+		order = false
+	}()
+
+	assert.Equal(t, &compare.Options{}, compareOptions())
+
+	execCmd, _, err := rootCmd.Find([]string{"exec"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, execCmd.ParseFlags([]string{"--decimal-norm", "--float-rel", "1e-9", "--order"}))
+	opts := compareOptions()
+	assert.True(t, opts.Order)
+	assert.True(t, opts.Tolerance.Decimal)
+	assert.Equal(t, 1e-9, opts.Tolerance.FloatRel)
+	assert.Equal(t, -1, opts.Tolerance.TemporalFsp)
+}
+
+func TestDumpSchemaDiffs(t *testing.T) {
+	diffs := []string{"column 1 `aaa`: type BIGINT vs DECIMAL"}
+	info := &dumpInfo{
+		sql:     "select * from test",
+		dsn1:    "dsn1",
+		dsn2:    "dsn2",
+		dsn1Res: &compare.QueryDsnRes{Res: mockRes1, SchemaDiffs: diffs},
+		dsn2Res: &compare.QueryDsnRes{Res: mockRes1, SchemaDiffs: diffs},
+	}
+
+	assert.True(t, strings.HasSuffix(info.String(), "[schema]\n\ncolumn 1 `aaa`: type BIGINT vs DECIMAL"))
+}
+
+type errDsnRes struct {
+	err error
+}
+
+func (e *errDsnRes) String() string {
+	return ""
+}
+
+func (e *errDsnRes) Err() error {
+	return e.err
+}
+
+func TestDumpErrCodes(t *testing.T) {
+	info := &dumpInfo{
+		sql:     "select * from test",
+		dsn1:    "dsn1",
+		dsn2:    "dsn2",
+		dsn1Res: &errDsnRes{&mysql.MySQLError{Number: 1064, Message: "mock"}},
+		dsn2Res: &errDsnRes{&mysql.MySQLError{Number: 1105, Message: "mock"}},
+	}
+
+	assert.True(t, strings.Contains(info.String(), `[err]
+
+[[dsn1]]
+
+Error 1064: mock
+
+[[dsn2]]
+
+Error 1105: mock`))
+}
+
+func TestLoadErrCodes(t *testing.T) {
+	reInitCmd()
+	_, err := executeCommand(rootCmd, "exec", "-Y", "yyy", "--dsn1", "d1", "--dsn2", "d2",
+		"--err-codes", "not_exist.json")
+	assert.True(t, strings.HasPrefix(err.Error(), "load error codes file not_exist.json fail"))
+}
+
+func TestDumpWarnings(t *testing.T) {
+	res1 := &compare.QueryDsnRes{Res: mockRes1}
+	res1.Warnings = []*compare.Warning{{Level: "Warning", Code: 1292, Message: "Truncated incorrect DOUBLE value: 'a'"}}
+	res1.WarningDiffs = []string{"count: 1 vs 0", "Warning 1292: 1 vs 0"}
+	res2 := &compare.QueryDsnRes{Res: mockRes1}
+	res2.WarningDiffs = res1.WarningDiffs
+	info := &dumpInfo{
+		sql:     "select * from test",
+		dsn1:    "dsn1",
+		dsn2:    "dsn2",
+		dsn1Res: res1,
+		dsn2Res: res2,
+	}
+
+	assert.True(t, strings.HasSuffix(info.String(), `[warnings]
+
+[[dsn1]]
+
+Warning 1292: Truncated incorrect DOUBLE value: 'a'
+
+[[dsn2]]
+
+count: 1 vs 0
+Warning 1292: 1 vs 0`))
+}
+
+func TestWarningsOptions(t *testing.T) {
+	reInitCmd()
+	defer func() {
+		compareWarnings = false
+		ignoreWarnings = nil
+	}()
+
+	execCmd, _, err := rootCmd.Find([]string{"exec"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, execCmd.ParseFlags([]string{"--warnings", "--ignore-warnings", "1292,1366"}))
+	opts := compareOptions()
+	assert.True(t, opts.Warnings)
+	assert.Equal(t, []uint16{1292, 1366}, opts.IgnoreWarnings)
+}
+
+func TestOrderByOptions(t *testing.T) {
+	reInitCmd()
+	_, err := executeCommand(rootCmd, "exec", "-Y", "yyy", "--dsn1", "d1", "--dsn2", "d2", "--order", "--order-by")
+	assert.Equal(t, "--order can not be used with --order-by", err.Error())
+
+	reInitCmd()
+	execCmd, _, err := rootCmd.Find([]string{"exec"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, execCmd.ParseFlags([]string{"--order-by"}))
+	assert.Equal(t, &compare.Options{OrderBy: true}, compareOptions())
+	orderBy = false
+}
+
+func TestTimeoutOptions(t *testing.T) {
+	reInitCmd()
+	defer func() {
+		stmtTimeout = 0
+	}()
+
+	execCmd, _, err := rootCmd.Find([]string{"exec"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, execCmd.ParseFlags([]string{"--timeout", "30s"}))
+	assert.Equal(t, 30*time.Second, compareOptions().Timeout)
+
+	info := &dumpInfo{
+		sql:     "select * from test",
+		dsn1:    "dsn1",
+		dsn2:    "dsn2",
+		dsn1Res: &errDsnRes{compare.ErrTimeout},
+		dsn2Res: &compare.QueryDsnRes{Res: mockRes1},
+	}
+	assert.True(t, strings.Contains(info.String(), "[err]\n\n[[dsn1]]\n\nstatement timeout\n\n[[dsn2]]\n\n"))
+}
+
+func TestDumpVisitor(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dump")
+	assert.Equal(t, nil, err)
+	defer os.RemoveAll(dir)
+	oldDumpDir := dumpDir
+	dumpDir = dir
+	defer func() { dumpDir = oldDumpDir }()
+
+	visitor := dumpVisitor("dsn1", "dsn2")
+	res := &compare.QueryDsnRes{Res: mockRes1}
+	assert.Equal(t, nil, visitor("select 1", res, res))
+	assert.Equal(t, nil, visitor("select 2", res, &errDsnRes{compare.ErrTimeout}))
+
+	bs, err := ioutil.ReadFile(filepath.Join(dir, "0.log"))
+	assert.Equal(t, nil, err)
+	assert.True(t, strings.HasPrefix(string(bs), "[sql]\n\nselect 1\n\n[kind]\n\nresult\n\n[err]"))
+	bs, err = ioutil.ReadFile(filepath.Join(dir, "1.log"))
+	assert.Equal(t, nil, err)
+	assert.True(t, strings.HasPrefix(string(bs), "[sql]\n\nselect 2\n\n[kind]\n\ntimeout\n\n[err]"))
+}
+
+func TestPlanDumpVisitor(t *testing.T) {
+	dir, err := ioutil.TempDir("", "plan_dump")
+	assert.Equal(t, nil, err)
+	defer os.RemoveAll(dir)
+	oldDumpDir := dumpDir
+	dumpDir = dir
+	defer func() { dumpDir = oldDumpDir }()
+
+	plan1 := &compare.PlanDsnRes{Res: mockRes1, Shape: []string{"IndexReader | root"}}
+	plan2 := &compare.PlanDsnRes{Res: mockRes1, Shape: []string{"TableReader | root"}}
+	visitor := planDumpVisitor("dsn1", "dsn2")
+	assert.Equal(t, nil, visitor("select a from t where a = 1", plan1, plan2))
+	assert.Equal(t, nil, visitor("select a from t where a = 2", plan1, plan2))
+
+	group := filepath.Join(dir, plan1.Signature()+"_"+plan2.Signature())
+	files, err := ioutil.ReadDir(group)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(files))
+
+	bs, err := ioutil.ReadFile(filepath.Join(group, "1.log"))
+	assert.Equal(t, nil, err)
+	assert.True(t, strings.HasPrefix(string(bs), "[sql]\n\nselect a from t where a = 2\n\n"))
+	assert.True(t, strings.Contains(string(bs), "[explain]\n\n[[dsn1]]\n\n+-------------+"))
+}
+
+func TestPerfDumpVisitor(t *testing.T) {
+	reInitCmd()
+	_, err := executeCommand(rootCmd, "exec", "-Y", "yyy", "--dsn1", "d1", "--dsn2", "d2", "--perf", "--plan")
+	assert.Equal(t, "--perf can not be used with --dsn and --plan", err.Error())
+	planMode, perfMode = false, false
+
+	dir, err := ioutil.TempDir("", "perf_dump")
+	assert.Equal(t, nil, err)
+	defer os.RemoveAll(dir)
+	oldPerfDumpDir := perfDumpDir
+	perfDumpDir = dir
+	defer func() { perfDumpDir = oldPerfDumpDir }()
+
+	stats := &compare.LatencyStats{}
+	visitor := perfDumpVisitor("dsn1", "dsn2", stats)
+	assert.Equal(t, nil, visitor(&compare.Latency{Sql: "select a from t",
+		Latency1: 200 * time.Millisecond, Latency2: 300 * time.Millisecond, Runs: 4}))
+	assert.Equal(t, nil, visitor(&compare.Latency{Sql: "select b from t",
+		Latency1: 200 * time.Millisecond, Latency2: 500 * time.Millisecond, Runs: 4}))
+
+	files, err := ioutil.ReadDir(dir)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, len(files))
+
+	bs, err := ioutil.ReadFile(filepath.Join(dir, "0.log"))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `[sql]
+
+select b from t
+
+[latency]
+
+[[dsn1]]
+
+200ms
+
+[[dsn2]]
+
+500ms
+
+ratio: 2.50, runs: 4`, string(bs))
+	assert.True(t, strings.HasSuffix(stats.String(), "statements: 2, regressions: 1"))
+}
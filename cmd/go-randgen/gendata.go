@@ -32,7 +32,8 @@ func newGenDataCmd() *cobra.Command {
 
 
 func gendataAction(cmd *cobra.Command, args []string) {
-	ddls, _ := getDdls()
+	tempDir := tempDataDir()
+	tables, _ := getDdls(tempDir)
 
 	targetDbs := make([]*sql.DB, 0, len(gendataDsns))
 	for _, dsn := range gendataDsns {
@@ -43,11 +44,8 @@ func gendataAction(cmd *cobra.Command, args []string) {
 		targetDbs = append(targetDbs, targetDb)
 	}
 
-	errSql, err := compare.ExecSqlsInDbs(ddls, targetDbs...)
-	if err != nil {
-		log.Printf("Fatal Error: data prepare ddl exec error %v\n", err)
-		log.Fatalln(errSql)
-	}
+	loadDdls(tables, targetDbs...)
+	removeTempDataDir(tempDir)
 
 	log.Printf("generate data in:\n %v ok\n", gendataDsns)
 }
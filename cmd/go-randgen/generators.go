@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"github.com/pingcap/go-randgen/gendata"
+	"github.com/pingcap/go-randgen/gendata/generators"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+var sampleNum int
+
+func newGeneratorsCmd() *cobra.Command {
+	generatorsCmd := &cobra.Command{
+		Use:   "generators",
+		Short: "list all generators which can be used in zz data and as yy keywords",
+		Run: func(cmd *cobra.Command, args []string) {
+			printGenerators(os.Stdout, sampleNum)
+		},
+	}
+
+	generatorsCmd.Flags().IntVar(&sampleNum, "samples", 3, "sample values of every generator")
+
+	return generatorsCmd
+}
+
+func printGenerators(out io.Writer, samples int) {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "USAGE\tDESCRIPTION\tSAMPLES")
+	for _, info := range generators.Infos() {
+		desc := info.Desc
+		if info.Stateful {
+			desc += " (stateful)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", info.Usage(), desc, strings.Join(info.Samples(samples), " "))
+	}
+	for _, info := range gendata.FieldGenerators() {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", info.Usage(), info.Desc+" (zz data only)", "depends on the field")
+	}
+	w.Flush()
+}
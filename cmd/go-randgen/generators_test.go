@@ -0,0 +1,25 @@
+package main
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestPrintGenerators(t *testing.T) {
+	buf := &bytes.Buffer{}
+	printGenerators(buf, 2)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.True(t, strings.HasPrefix(lines[0], "USAGE"))
+
+	found := make(map[string]string)
+	for _, line := range lines[1:] {
+		found[strings.Fields(line)[0]] = line
+	}
+	assert.Contains(t, found, "int,")
+	assert.Contains(t, found, "int_unsigned")
+	assert.Contains(t, found, "choice(value,")
+	assert.Contains(t, found, "member")
+	assert.Regexp(t, `1 2$`, found["seq"])
+}
@@ -50,7 +50,9 @@ func gentestAction(cmd *cobra.Command, args []string) {
 	var ddls []string
 
 	if !skipZz {
-		ddls, keyf = getDdls()
+		var tables []*gendata.TableSqls
+		tables, keyf = getDdls(outPath + "_data")
+		ddls = flatDdls(tables)
 	} else {
 		keyf = gendata.NewKeyfun(nil, nil)
 	}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/pingcap/go-randgen/compare"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+type perfDumpInfo struct {
+	dsn1    string
+	dsn2    string
+	latency *compare.Latency
+}
+
+func (dump *perfDumpInfo) String() string {
+	bs := &bytes.Buffer{}
+
+	// [sql]
+	bs.WriteString("[sql]\n\n")
+	bs.WriteString(dump.latency.Sql + "\n\n")
+
+	// [latency]
+	bs.WriteString("[latency]\n\n")
+	bs.WriteString(fmt.Sprintf("[[%s]]\n\n", dump.dsn1))
+	bs.WriteString(dump.latency.Latency1.String() + "\n\n")
+	bs.WriteString(fmt.Sprintf("[[%s]]\n\n", dump.dsn2))
+	bs.WriteString(dump.latency.Latency2.String() + "\n\n")
+	bs.WriteString(fmt.Sprintf("ratio: %.2f, runs: %d", dump.latency.Ratio(), dump.latency.Runs))
+
+	return bs.String()
+}
+
+// dump sqls whose latencies regressed into perf dump dir,
+// and collect latencies of all sqls into stats
+func perfDumpVisitor(dsn1, dsn2 string, stats *compare.LatencyStats) func(latency *compare.Latency) error {
+	count := 0
+	return func(latency *compare.Latency) error {
+		regressed := perf.Regressed(latency)
+		stats.Add(latency, regressed)
+		if !regressed {
+			return nil
+		}
+
+		log.Printf("latency regression %d, %s\n", count, latency)
+		info := &perfDumpInfo{dsn1: dsn1, dsn2: dsn2, latency: latency}
+		err := ioutil.WriteFile(filepath.Join(perfDumpDir,
+			fmt.Sprintf("%d.log", count)), []byte(info.String()), os.ModePerm)
+		if err != nil {
+			return err
+		}
+		count++
+		return nil
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/pingcap/go-randgen/compare"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type voteDumpInfo struct {
+	dsns []string
+	vote *compare.Vote
+}
+
+func (dump *voteDumpInfo) names(indexes []int) string {
+	names := make([]string, 0, len(indexes))
+	for _, i := range indexes {
+		names = append(names, dump.dsns[i])
+	}
+	return strings.Join(names, ", ")
+}
+
+func (dump *voteDumpInfo) String() string {
+	bs := &bytes.Buffer{}
+
+	// [sql]
+	bs.WriteString("[sql]\n\n")
+	bs.WriteString(dump.vote.Sql + "\n\n")
+
+	// [vote]
+	bs.WriteString("[vote]\n\n")
+	if majority := dump.vote.Majority(); majority != nil {
+		bs.WriteString("majority: " + dump.names(majority) + "\n")
+	} else {
+		bs.WriteString("majority: none\n")
+	}
+	bs.WriteString("outliers: " + dump.names(dump.vote.Outliers()))
+	if len(dump.vote.Ignored) > 0 {
+		bs.WriteString("\nignored: " + dump.names(dump.vote.Ignored))
+	}
+
+	// [group n]
+	for g, group := range dump.vote.Groups {
+		bs.WriteString(fmt.Sprintf("\n\n[group %d]\n\n", g+1))
+		for _, i := range group {
+			bs.WriteString(fmt.Sprintf("[[%s]]\n\n", dump.dsns[i]))
+		}
+		res := dump.vote.Results[group[0]]
+		if res.Err() != nil {
+			bs.WriteString(res.Err().Error())
+		} else {
+			bs.WriteString(res.String())
+		}
+	}
+
+	return bs.String()
+}
+
+// dump inconsistent sqls of N dsns and the dsns which
+// disagreed with the majority into dump dir
+func voteDumpVisitor(dsns []string) compare.VoteVisitor {
+	count := 0
+	return func(vote *compare.Vote) error {
+		info := &voteDumpInfo{dsns: dsns, vote: vote}
+		log.Printf("inconsistent sql %d, outliers: %s\n", count, info.names(vote.Outliers()))
+
+		err := ioutil.WriteFile(filepath.Join(dumpDir,
+			fmt.Sprintf("%d.log", count)), []byte(info.String()), os.ModePerm)
+		if err != nil {
+			return err
+		}
+		count++
+		return nil
+	}
+}
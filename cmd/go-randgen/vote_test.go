@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"github.com/pingcap/go-randgen/compare"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestVoteDumpInfo(t *testing.T) {
+	res := &compare.QueryDsnRes{Res: mockRes1}
+	info := &voteDumpInfo{
+		dsns: []string{"tidb", "mysql57", "mysql80"},
+		vote: &compare.Vote{
+			Sql:     "select * from test",
+			Results: []compare.DsnRes{&errDsnRes{errors.New("mock")}, res, res},
+			Groups:  [][]int{{1, 2}, {0}},
+		},
+	}
+
+	expected := `[sql]
+
+select * from test
+
+[vote]
+
+majority: mysql57, mysql80
+outliers: tidb
+
+[group 1]
+
+[[mysql57]]
+
+[[mysql80]]
+
++-------------+
+| aaa  | bbbb |
++-------------+
+| haha | baba |
+| mmmm | popo |
++-------------+
+
+[group 2]
+
+[[tidb]]
+
+mock`
+	assert.Equal(t, expected, info.String())
+
+	// dsns with ignorable errors do not vote
+	info.vote.Groups = [][]int{{1}, {2}}
+	info.vote.Ignored = []int{0}
+	assert.True(t, strings.Contains(info.String(), "majority: none\noutliers: mysql57, mysql80\nignored: tidb\n\n"))
+}
+
+func TestExecDsns(t *testing.T) {
+	reInitCmd()
+	_, err := executeCommand(rootCmd, "exec", "-Y", "yyy", "--dsn", "d1")
+	assert.Equal(t, "--dsn must be given at least twice", err.Error())
+
+	reInitCmd()
+	_, err = executeCommand(rootCmd, "exec", "-Y", "yyy", "--dsn", "d1", "--dsn", "d2", "--dsn1", "d3")
+	assert.Equal(t, "--dsn can not be used with --dsn1 and --dsn2", err.Error())
+}
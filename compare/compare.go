@@ -1,12 +1,14 @@
 package compare
 
 import (
+	"context"
 	"database/sql"
 	"database/sql/driver"
 	"fmt"
 	"log"
 	"strconv"
 	"sync"
+	"time"
 )
 
 type DsnRes interface {
@@ -17,6 +19,11 @@ type DsnRes interface {
 type QueryDsnRes struct {
 	Res *SqlResult
 	err error
+	// differences of result schema from the other dsn, see Options.Schema
+	SchemaDiffs []string
+	StmtWarnings
+	// time of running the query
+	Elapsed time.Duration
 }
 
 func (q *QueryDsnRes) Err() error {
@@ -27,14 +34,23 @@ func (q *QueryDsnRes) String() string {
 	return q.Res.String()
 }
 
-func newQueryDsnRes(db *sql.DB, sql string) *QueryDsnRes {
-	result, err := query(db, sql)
-	return &QueryDsnRes{result, err}
+func newQueryDsnRes(db *sql.DB, sql string, opts *Options) *QueryDsnRes {
+	res := &QueryDsnRes{}
+	res.Warnings, res.Elapsed, res.err = opts.run(db, func(ctx context.Context, s Session) (err error) {
+		res.Res, err = query(ctx, s, sql)
+		return err
+	})
+	if res.err == ErrTimeout {
+		res.Res = nil
+	}
+	return res
 }
 
 type execDsnRes struct {
 	rowsAffected int64
 	err          error
+	StmtWarnings
+	elapsed time.Duration
 }
 
 func (e *execDsnRes) String() string {
@@ -45,13 +61,90 @@ func (e *execDsnRes) Err() error {
 	return e.err
 }
 
-func newExecDsnRes(db *sql.DB, sql string) *execDsnRes {
-	r, err := exec(db, sql)
-	return &execDsnRes{r, err}
+func newExecDsnRes(db *sql.DB, sql string, opts *Options) *execDsnRes {
+	res := &execDsnRes{}
+	res.Warnings, res.elapsed, res.err = opts.run(db, func(ctx context.Context, s Session) (err error) {
+		res.rowsAffected, err = exec(ctx, s, sql)
+		return err
+	})
+	return res
 }
 
 type Visitor func(sql string, dsn1Res DsnRes, dsn2Res DsnRes) error
 
+// Options of comparing results
+type Options struct {
+	// compare rows of query results in order
+	Order bool
+	// compare rows in the order of the outermost ORDER BY if Order is false,
+	// rows with tied sort keys can be in any order, see PartialOrderEqualTo
+	OrderBy bool
+	// rules of comparing values, nil means comparing bytes
+	Tolerance *Tolerance
+	// compare column names, types, nullability, length,
+	// precision and scale of query results
+	Schema bool
+	// equivalent and ignorable error codes, nil means errors
+	// are consistent only if they have the same error numbers
+	ErrCodes *ErrCodes
+	// compare level, code and count of SHOW WARNINGS after every
+	// statement, warnings with codes in IgnoreWarnings are skipped
+	Warnings       bool
+	IgnoreWarnings []uint16
+	// cancel a statement after Timeout and kill it in the server,
+	// zero means no timeout, see ErrTimeout
+	Timeout time.Duration
+}
+
+// kinds of inconsistency
+const (
+	KindErr     = "error"
+	KindTimeout = "timeout"
+	KindResult  = "result"
+	KindSchema  = "schema"
+	KindWarning = "warning"
+)
+
+// Kind returns the kind of inconsistency of two results
+func Kind(dsn1Res DsnRes, dsn2Res DsnRes) string {
+	if dsn1Res.Err() == ErrTimeout || dsn2Res.Err() == ErrTimeout {
+		return KindTimeout
+	}
+	if dsn1Res.Err() != nil || dsn2Res.Err() != nil {
+		return KindErr
+	}
+	if res, ok := dsn1Res.(*QueryDsnRes); ok && len(res.SchemaDiffs) > 0 {
+		return KindSchema
+	}
+	if w := WarningsOf(dsn1Res); w != nil && w.onlyWarnings {
+		return KindWarning
+	}
+	return KindResult
+}
+
+// equal compares query results of sql by options
+func (o *Options) equal(sql string, res1 *SqlResult, res2 *SqlResult) bool {
+	if o.OrderBy && !o.Order {
+		if orderBy := ParseOrderBy(sql); orderBy != nil {
+			if keys := orderBy.KeyColumns(res1.Header); len(keys) > 0 {
+				return res1.PartialOrderEqualTo(res2, keys, orderBy.Limit, orderBy.Offset, o.Tolerance)
+			}
+		}
+	}
+
+	if o.Tolerance == nil {
+		if o.Order {
+			return res1.BytesEqualTo(res2)
+		}
+		return res1.NonOrderEqualTo(res2)
+	}
+
+	if o.Order {
+		return res1.EqualTo(res2, o.Tolerance)
+	}
+	return res1.NonOrderEqualToWith(res2, o.Tolerance)
+}
+
 func ByDsn(sqls []string, dsn1 string, dsn2 string, nonOrder bool, visitor Visitor) error {
 
 	db1, err := cache.initDb(dsn1)
@@ -68,13 +161,17 @@ func ByDsn(sqls []string, dsn1 string, dsn2 string, nonOrder bool, visitor Visit
 }
 
 func ByDb(sqls []string, db1 *sql.DB, db2 *sql.DB, nonOrder bool, visitor Visitor) error {
+	return ByDbWithOptions(sqls, db1, db2, &Options{Order: !nonOrder}, visitor)
+}
+
+func ByDbWithOptions(sqls []string, db1 *sql.DB, db2 *sql.DB, opts *Options, visitor Visitor) error {
 
 	for _, sql := range sqls {
 		if sql == "" {
 			continue
 		}
 
-		consistent, dsn1Res, dsn2Res := BySql(sql, db1, db2, nonOrder)
+		consistent, dsn1Res, dsn2Res := BySqlWithOptions(sql, db1, db2, opts)
 
 		if !consistent {
 			if err := visitor(sql, dsn1Res, dsn2Res); err != nil {
@@ -88,15 +185,25 @@ func ByDb(sqls []string, db1 *sql.DB, db2 *sql.DB, nonOrder bool, visitor Visito
 
 func BySql(sql string, db1 *sql.DB, db2 *sql.DB, nonOrder bool) (consistent bool, dsn1Res DsnRes,
 	dsn2Res DsnRes) {
+	return BySqlWithOptions(sql, db1, db2, &Options{Order: !nonOrder})
+}
+
+func BySqlWithOptions(sql string, db1 *sql.DB, db2 *sql.DB, opts *Options) (consistent bool,
+	dsn1Res DsnRes, dsn2Res DsnRes) {
 	if isExec(sql) {
-		return ByExec(sql, db1, db2)
+		return ByExecWithOptions(sql, db1, db2, opts)
 	} else {
-		return ByQuery(sql, db1, db2, nonOrder)
+		return ByQueryWithOptions(sql, db1, db2, opts)
 	}
 }
 
 func ByQuery(sql string, db1 *sql.DB, db2 *sql.DB, nonOrder bool) (consistent bool, dsn1Res DsnRes,
 	dsn2Res DsnRes) {
+	return ByQueryWithOptions(sql, db1, db2, &Options{Order: !nonOrder})
+}
+
+func ByQueryWithOptions(sql string, db1 *sql.DB, db2 *sql.DB, opts *Options) (consistent bool,
+	dsn1Res DsnRes, dsn2Res DsnRes) {
 
 	var res1 *QueryDsnRes
 	var res2 *QueryDsnRes
@@ -105,12 +212,12 @@ func ByQuery(sql string, db1 *sql.DB, db2 *sql.DB, nonOrder bool) (consistent bo
 	wg.Add(2)
 
 	go func() {
-		res1 = newQueryDsnRes(db1, sql)
+		res1 = newQueryDsnRes(db1, sql, opts)
 		wg.Done()
 	}()
 
 	go func() {
-		res2 = newQueryDsnRes(db2, sql)
+		res2 = newQueryDsnRes(db2, sql, opts)
 		wg.Done()
 	}()
 
@@ -124,31 +231,37 @@ func ByQuery(sql string, db1 *sql.DB, db2 *sql.DB, nonOrder bool) (consistent bo
 		log.Printf("Error: connection to dsn2 error, %v \n", res2.err)
 	}
 
-	if !errConsistent(res1.err, res2.err) {
-		return false, res1, res2
-	}
+	return opts.queryConsistent(sql, res1, res2), res1, res2
+}
 
-	// err all not nil, think it is consistent without need to compare
-	if res1.err != nil && res2.err != nil {
-		return true, res1, res2
+// queryConsistent compares query results of sql by options, and
+// saves differences of schema and warnings in them
+func (o *Options) queryConsistent(sql string, res1 *QueryDsnRes, res2 *QueryDsnRes) bool {
+	if !o.ErrCodes.Consistent(res1.err, res2.err) {
+		return false
 	}
 
-	// compare
-	if nonOrder {
-		if !res1.Res.NonOrderEqualTo(res2.Res) {
-			return false, res1, res2
-		}
-	} else {
-		if !res1.Res.BytesEqualTo(res2.Res) {
-			return false, res1, res2
-		}
+	// consistent errors or an ignorable error, no need to compare
+	if res1.err != nil || res2.err != nil {
+		return true
 	}
 
-	return true, res1, res2
+	consistent := o.equal(sql, res1.Res, res2.Res)
+	if o.Schema {
+		res1.SchemaDiffs = SchemaDiffs(res1.Res.Schema(), res2.Res.Schema())
+		res2.SchemaDiffs = res1.SchemaDiffs
+		consistent = consistent && len(res1.SchemaDiffs) == 0
+	}
+	return o.compareWarnings(&res1.StmtWarnings, &res2.StmtWarnings, consistent)
 }
 
 func ByExec(sql string, db1 *sql.DB, db2 *sql.DB) (consistent bool, dsn1Res DsnRes,
 	dsn2Res DsnRes) {
+	return ByExecWithOptions(sql, db1, db2, &Options{})
+}
+
+func ByExecWithOptions(sql string, db1 *sql.DB, db2 *sql.DB, opts *Options) (consistent bool,
+	dsn1Res DsnRes, dsn2Res DsnRes) {
 
 	var res1 *execDsnRes
 	var res2 *execDsnRes
@@ -157,12 +270,12 @@ func ByExec(sql string, db1 *sql.DB, db2 *sql.DB) (consistent bool, dsn1Res DsnR
 	wg.Add(2)
 
 	go func() {
-		res1 = newExecDsnRes(db1, sql)
+		res1 = newExecDsnRes(db1, sql, opts)
 		wg.Done()
 	}()
 
 	go func() {
-		res2 = newExecDsnRes(db2, sql)
+		res2 = newExecDsnRes(db2, sql, opts)
 		wg.Done()
 	}()
 
@@ -176,21 +289,18 @@ func ByExec(sql string, db1 *sql.DB, db2 *sql.DB) (consistent bool, dsn1Res DsnR
 		log.Printf("Error: connection to dsn2 error, %v \n", res2.err)
 	}
 
-	if !errConsistent(res1.err, res2.err) {
-		return false, res1, res2
-	}
+	return opts.execConsistent(res1, res2), res1, res2
+}
 
-	if res1.err != nil && res2.err != nil {
-		return true, res1, res2
+// execConsistent compares exec results by options
+func (o *Options) execConsistent(res1 *execDsnRes, res2 *execDsnRes) bool {
+	if !o.ErrCodes.Consistent(res1.err, res2.err) {
+		return false
 	}
 
-	if res1.rowsAffected != res2.rowsAffected {
-		return false, res1, res2
+	if res1.err != nil || res2.err != nil {
+		return true
 	}
 
-	return true, res1, res2
-}
-
-func errConsistent(err1 error, err2 error) bool {
-	return (err1 == nil && err2 == nil) || (err1 != nil && err2 != nil)
+	return o.compareWarnings(&res1.StmtWarnings, &res2.StmtWarnings, res1.rowsAffected == res2.rowsAffected)
 }
@@ -244,10 +244,32 @@ func TestSimplePrint(t *testing.T)  {
 	db2, err := OpenDBWithRetry("mysql", "root:123456@tcp(127.0.0.1:4406)/randgen")
 	assert.Equal(t, nil, err)
 
-	res1 := newQueryDsnRes(db1, sql)
-	res2 := newQueryDsnRes(db2, sql)
+	res1 := newQueryDsnRes(db1, sql, &Options{})
+	res2 := newQueryDsnRes(db2, sql, &Options{})
 	assert.Equal(t, nil, res1.err)
 	assert.Equal(t, nil, res2.err)
 
 	fmt.Println(res1.Res.NonOrderEqualTo(res2.Res))
 }
+
+func TestBySchema(t *testing.T) {
+	sql := "SELECT a FROM t"
+	db1, mock1, err := sqlmock.New()
+	assert.Equal(t, nil, err)
+	db2, mock2, err := sqlmock.New()
+	assert.Equal(t, nil, err)
+	mock1.ExpectQuery(sql).WillReturnRows(getRows([]string{"a"}, [][]driver.Value{{1}}))
+	mock2.ExpectQuery(sql).WillReturnRows(getRows([]string{"b"}, [][]driver.Value{{1}}))
+
+	consistent, res1, res2 := ByQueryWithOptions(sql, db1, db2, &Options{Schema: true})
+	assert.False(t, consistent)
+	assert.Equal(t, []string{"column 1 `a`: name a vs b"}, res1.(*QueryDsnRes).SchemaDiffs)
+	assert.Equal(t, KindSchema, Kind(res1, res2))
+
+	// the schema comes from the ColumnTypes of the driver rows
+	mock1.ExpectQuery(sql).WillReturnRows(getRows([]string{"a"}, [][]driver.Value{{"abc"}}))
+	mock2.ExpectQuery(sql).WillReturnRows(getRows([]string{"a"}, [][]driver.Value{{"abc"}}))
+	consistent, res1, _ = ByQueryWithOptions(sql, db1, db2, &Options{Schema: true})
+	assert.True(t, consistent)
+	assert.Equal(t, []*Column{{Name: "a"}}, res1.(*QueryDsnRes).Res.Schema())
+}
@@ -0,0 +1,121 @@
+package compare
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"io/ioutil"
+	"strconv"
+)
+
+// ErrNumberOf returns the error number of a mysql error, ok is false
+// for other errors. The mysql driver does not return SQLSTATE
+func ErrNumberOf(err error) (number uint16, ok bool) {
+	myErr, ok := err.(*mysql.MySQLError)
+	if !ok {
+		return 0, false
+	}
+	return myErr.Number, true
+}
+
+// ErrCodes declares equivalent and ignorable error numbers of two dbs.
+// Errors without numbers (not mysql errors) are equal to any error
+type ErrCodes struct {
+	// error number -> index of its equivalent group
+	groups map[uint16]int
+	ignore map[uint16]bool
+}
+
+// error codes file, like
+// {"equivalent": [["1105", "1064"]], "ignore": ["1205", "1213"]}
+type errCodesFile struct {
+	Equivalent [][]string `json:"equivalent"`
+	Ignore     []string   `json:"ignore"`
+}
+
+func parseNumber(code string) (uint16, error) {
+	number, err := strconv.ParseUint(code, 10, 16)
+	if err != nil {
+		return 0, fmt.Errorf("illegal error number %s", code)
+	}
+	return uint16(number), nil
+}
+
+// ParseErrCodes parses the json content of error codes file
+func ParseErrCodes(data []byte) (*ErrCodes, error) {
+	file := &errCodesFile{}
+	if err := json.Unmarshal(data, file); err != nil {
+		return nil, err
+	}
+
+	codes := &ErrCodes{make(map[uint16]int), make(map[uint16]bool)}
+	for i, group := range file.Equivalent {
+		for _, code := range group {
+			number, err := parseNumber(code)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := codes.groups[number]; ok {
+				return nil, fmt.Errorf("error code %s is in more than one equivalent group", code)
+			}
+			codes.groups[number] = i
+		}
+	}
+	for _, code := range file.Ignore {
+		number, err := parseNumber(code)
+		if err != nil {
+			return nil, err
+		}
+		codes.ignore[number] = true
+	}
+	return codes, nil
+}
+
+// LoadErrCodes loads error codes file
+func LoadErrCodes(path string) (*ErrCodes, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseErrCodes(data)
+}
+
+func (e *ErrCodes) ignored(err error) bool {
+	number, ok := ErrNumberOf(err)
+	return ok && e != nil && e.ignore[number]
+}
+
+func (e *ErrCodes) equivalent(number1 uint16, number2 uint16) bool {
+	if number1 == number2 {
+		return true
+	}
+	if e == nil {
+		return false
+	}
+	group1, ok1 := e.groups[number1]
+	group2, ok2 := e.groups[number2]
+	return ok1 && ok2 && group1 == group2
+}
+
+// Consistent returns whether errors of two dbs are consistent, they are
+// both nil, either is ignorable, both are ErrTimeout, or both are errors
+// with equivalent numbers.
+// e can be nil, which means only the same error numbers are equivalent
+func (e *ErrCodes) Consistent(err1 error, err2 error) bool {
+	if e.ignored(err1) || e.ignored(err2) {
+		return true
+	}
+	if t1, t2 := err1 == ErrTimeout, err2 == ErrTimeout; t1 || t2 {
+		return t1 && t2
+	}
+	if err1 == nil || err2 == nil {
+		return err1 == nil && err2 == nil
+	}
+
+	number1, ok1 := ErrNumberOf(err1)
+	number2, ok2 := ErrNumberOf(err2)
+	if !ok1 || !ok2 {
+		return true
+	}
+	return e.equivalent(number1, number2)
+}
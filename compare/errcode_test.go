@@ -0,0 +1,86 @@
+package compare
+
+import (
+	"database/sql/driver"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func myErr(number uint16) error {
+	return &mysql.MySQLError{Number: number, Message: "mock"}
+}
+
+func TestErrNumberOf(t *testing.T) {
+	number, ok := ErrNumberOf(myErr(1064))
+	assert.True(t, ok)
+	assert.Equal(t, uint16(1064), number)
+
+	_, ok = ErrNumberOf(errors.New("mock"))
+	assert.False(t, ok)
+}
+
+func TestParseErrCodes(t *testing.T) {
+	_, err := ParseErrCodes([]byte(`{"equivalent": [["1105", "1064"], ["1064"]]}`))
+	assert.Equal(t, "error code 1064 is in more than one equivalent group", err.Error())
+
+	// SQLSTATE is not returned by the mysql driver
+	_, err = ParseErrCodes([]byte(`{"ignore": ["sqlstate:40001"]}`))
+	assert.Equal(t, "illegal error number sqlstate:40001", err.Error())
+
+	_, err = ParseErrCodes([]byte(`{"ignore": ["lock"]}`))
+	assert.Equal(t, "illegal error number lock", err.Error())
+
+	_, err = ParseErrCodes([]byte(`{"ignore": [1205]}`))
+	assert.NotEqual(t, nil, err)
+}
+
+func TestErrCodesConsistent(t *testing.T) {
+	var nilCodes *ErrCodes
+	assert.True(t, nilCodes.Consistent(nil, nil))
+	assert.True(t, nilCodes.Consistent(myErr(1064), myErr(1064)))
+	assert.False(t, nilCodes.Consistent(myErr(1064), myErr(1105)))
+	assert.False(t, nilCodes.Consistent(myErr(1205), nil))
+	// errors without codes
+	assert.True(t, nilCodes.Consistent(errors.New("mock"), myErr(1105)))
+	assert.False(t, nilCodes.Consistent(errors.New("mock"), nil))
+
+	codes, err := ParseErrCodes([]byte(`{
+  "equivalent": [["1105", "1064"], ["1146", "1054"]],
+  "ignore": ["1205", "1213"]
+}`))
+	assert.Equal(t, nil, err)
+	assert.True(t, codes.Consistent(myErr(1064), myErr(1105)))
+	assert.True(t, codes.Consistent(myErr(1146), myErr(1054)))
+	assert.False(t, codes.Consistent(myErr(1064), myErr(1054)))
+	assert.True(t, codes.Consistent(myErr(1205), nil))
+	assert.True(t, codes.Consistent(nil, myErr(1213)))
+	assert.False(t, codes.Consistent(myErr(1062), nil))
+}
+
+func TestByErrCodes(t *testing.T) {
+	sql := "SELECT a FROM t"
+	db1, mock1, err := sqlmock.New()
+	assert.Equal(t, nil, err)
+	db2, mock2, err := sqlmock.New()
+	assert.Equal(t, nil, err)
+	mock1.ExpectQuery(sql).WillReturnError(myErr(1064))
+	mock2.ExpectQuery(sql).WillReturnError(myErr(1105))
+	mock1.ExpectQuery(sql).WillReturnError(myErr(1064))
+	mock2.ExpectQuery(sql).WillReturnError(myErr(1105))
+	mock1.ExpectQuery(sql).WillReturnError(myErr(1205))
+	mock2.ExpectQuery(sql).WillReturnRows(getRows([]string{"a"}, [][]driver.Value{{1}}))
+
+	consistent, res1, res2 := ByQueryWithOptions(sql, db1, db2, &Options{})
+	assert.False(t, consistent)
+	assert.Equal(t, KindErr, Kind(res1, res2))
+
+	codes, err := ParseErrCodes([]byte(`{"equivalent": [["1105", "1064"]], "ignore": ["1205"]}`))
+	assert.Equal(t, nil, err)
+	consistent, _, _ = ByQueryWithOptions(sql, db1, db2, &Options{ErrCodes: codes})
+	assert.True(t, consistent)
+	consistent, _, _ = ByQueryWithOptions(sql, db1, db2, &Options{ErrCodes: codes})
+	assert.True(t, consistent)
+}
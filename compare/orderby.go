@@ -0,0 +1,275 @@
+package compare
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// OrderBy is the outermost ORDER BY of a sql
+type OrderBy struct {
+	// sort key expressions without ASC or DESC
+	Items []string
+	// row count of the LIMIT after the ORDER BY, -1 if there is not
+	// one or the row count is not a number
+	Limit int
+	// the LIMIT has a non-zero offset, like `LIMIT 5, 10` or `LIMIT 10 OFFSET 5`
+	Offset bool
+}
+
+// ParseOrderBy finds the ORDER BY out of any parentheses and quotes,
+// nil if there is not one
+func ParseOrderBy(sql string) *OrderBy {
+	// positions of keywords out of parentheses and quotes
+	orderBy, end, limit := -1, len(sql), false
+	depth := 0
+	var quote rune
+	escaped := false
+	for i, c := range sql {
+		switch {
+		case escaped:
+			escaped = false
+		case quote != 0:
+			if c == '\\' {
+				escaped = true
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case depth == 0 && isWordStart(sql, i):
+			switch {
+			case hasKeywords(sql[i:], "order", "by"):
+				orderBy, end, limit = i, len(sql), false
+			case orderBy >= 0 && end == len(sql) &&
+				(hasKeywords(sql[i:], "limit") || hasKeywords(sql[i:], "for") ||
+					hasKeywords(sql[i:], "lock", "in")):
+				end, limit = i, hasKeywords(sql[i:], "limit")
+			}
+		}
+	}
+	if orderBy < 0 {
+		return nil
+	}
+
+	body := strings.TrimSpace(sql[orderBy:end])
+	body = strings.TrimSpace(body[len("order"):])
+	body = strings.TrimSpace(body[len("by"):])
+	body = strings.TrimRight(body, "; \t\n")
+	items := make([]string, 0)
+	for _, item := range splitTopLevel(body) {
+		fields := strings.Fields(item)
+		if n := len(fields); n > 1 {
+			if dir := strings.ToLower(fields[n-1]); dir == "asc" || dir == "desc" {
+				item = strings.TrimSpace(item[:strings.LastIndex(item, fields[n-1])])
+			}
+		}
+		items = append(items, item)
+	}
+	res := &OrderBy{Items: items, Limit: -1}
+	if limit {
+		res.Limit, res.Offset = parseLimit(sql[end+len("limit"):])
+	}
+	return res
+}
+
+// limit is the rest of a LIMIT, like ` 10 OFFSET 5 FOR UPDATE`,
+// count is -1 if the row count is not a number
+func parseLimit(limit string) (count int, offset bool) {
+	fields := strings.Fields(strings.Replace(strings.TrimRight(limit, "; \t\n"), ",", " , ", 1))
+	if len(fields) == 0 {
+		return -1, false
+	}
+	rowCount, offsetVal := fields[0], ""
+	if len(fields) >= 3 && fields[1] == "," {
+		rowCount, offsetVal = fields[2], fields[0]
+	} else if len(fields) >= 3 && strings.EqualFold(fields[1], "offset") {
+		offsetVal = fields[2]
+	}
+	count, err := strconv.Atoi(rowCount)
+	if err != nil || count < 0 {
+		count = -1
+	}
+	return count, offsetVal != "" && offsetVal != "0"
+}
+
+func isWordStart(sql string, i int) bool {
+	return i == 0 || !isWordChar(rune(sql[i-1]))
+}
+
+func isWordChar(c rune) bool {
+	return c == '_' || unicode.IsLetter(c) || unicode.IsDigit(c)
+}
+
+// s starts with the keywords separated by spaces
+func hasKeywords(s string, keywords ...string) bool {
+	for i, keyword := range keywords {
+		if i > 0 {
+			trimmed := strings.TrimLeftFunc(s, unicode.IsSpace)
+			if len(trimmed) == len(s) {
+				return false
+			}
+			s = trimmed
+		}
+		if len(s) < len(keyword) || !strings.EqualFold(s[:len(keyword)], keyword) {
+			return false
+		}
+		s = s[len(keyword):]
+		if len(s) > 0 && isWordChar(rune(s[0])) {
+			return false
+		}
+	}
+	return true
+}
+
+// split s by commas out of parentheses and quotes
+func splitTopLevel(s string) []string {
+	parts := make([]string, 0)
+	depth, start := 0, 0
+	var quote rune
+	escaped := false
+	for i, c := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case quote != 0:
+			if c == '\\' {
+				escaped = true
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			parts = append(parts, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	if last := strings.TrimSpace(s[start:]); last != "" {
+		parts = append(parts, last)
+	}
+	return parts
+}
+
+// `t`.`a` -> t.a, a + 1 -> a+1
+func normalizeName(name string) string {
+	name = strings.Replace(name, "`", "", -1)
+	return strings.ToLower(strings.Join(strings.Fields(name), ""))
+}
+
+// KeyColumns returns indexes of result columns of the sort keys by positions,
+// names or aliases, the keys after the first unknown one are dropped
+func (o *OrderBy) KeyColumns(header []string) []int {
+	names := make([]string, len(header))
+	for i, h := range header {
+		names[i] = normalizeName(h)
+	}
+	find := func(name string) int {
+		for i, n := range names {
+			if n == name {
+				return i
+			}
+		}
+		return -1
+	}
+
+	keys := make([]int, 0, len(o.Items))
+	for _, item := range o.Items {
+		col := -1
+		if pos, err := strconv.Atoi(item); err == nil {
+			if pos >= 1 && pos <= len(header) {
+				col = pos - 1
+			}
+		} else {
+			name := normalizeName(item)
+			if col = find(name); col < 0 {
+				if dot := strings.LastIndex(name, "."); dot >= 0 {
+					col = find(name[dot+1:])
+				}
+			}
+		}
+		if col < 0 {
+			break
+		}
+		keys = append(keys, col)
+	}
+	return keys
+}
+
+// rows sorted by keys are split into groups of tied keys
+func (s *SqlResult) tiedGroups(keys []int, kinds []int, t *Tolerance) [][][][]byte {
+	groups := make([][][][]byte, 0)
+	for r, row := range s.Data {
+		if r == 0 || !keysEqual(keys, kinds, kinds, s.Data[r-1], row, t) {
+			groups = append(groups, make([][][]byte, 0))
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], row)
+	}
+	return groups
+}
+
+func keysEqual(keys []int, kinds1 []int, kinds2 []int, row1 [][]byte, row2 [][]byte, t *Tolerance) bool {
+	for _, c := range keys {
+		if c >= len(row1) || c >= len(row2) {
+			return false
+		}
+		if t == nil {
+			if (row1[c] == nil) != (row2[c] == nil) || !bytes.Equal(row1[c], row2[c]) {
+				return false
+			}
+		} else if !t.valueEqual(colKind(kinds1, kinds2, c), row1[c], row2[c]) {
+			return false
+		}
+	}
+	return true
+}
+
+// PartialOrderEqualTo compares results sorted by the key columns, groups of tied
+// keys must be in the same order, but rows in a group can be in any order. If the
+// results are cut by the LIMIT of limit rows (negative if there is not one), the
+// last group can be any rows with the tied keys, so only its keys and size are
+// compared, and so is the first group if there is an offset.
+// Values are compared by bytes if t is nil
+func (s *SqlResult) PartialOrderEqualTo(another *SqlResult, keys []int, limit int, offset bool, t *Tolerance) bool {
+	if len(s.Data) != len(another.Data) {
+		return false
+	}
+
+	// a result shorter than the LIMIT is not cut
+	limited := limit >= 0 && len(s.Data) == limit
+	kinds1, kinds2 := s.kinds(), another.kinds()
+	groups1 := s.tiedGroups(keys, kinds1, t)
+	groups2 := another.tiedGroups(keys, kinds2, t)
+	if len(groups1) != len(groups2) {
+		return false
+	}
+
+	for g := range groups1 {
+		group1, group2 := groups1[g], groups2[g]
+		if len(group1) != len(group2) || !keysEqual(keys, kinds1, kinds2, group1[0], group2[0], t) {
+			return false
+		}
+		if (limited && g == len(groups1)-1) || (offset && g == 0) {
+			continue
+		}
+
+		res1 := &SqlResult{Data: group1, typeNames: s.typeNames}
+		res2 := &SqlResult{Data: group2, typeNames: another.typeNames}
+		if t == nil && !res1.NonOrderEqualTo(res2) {
+			return false
+		}
+		if t != nil && !res1.NonOrderEqualToWith(res2, t) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,114 @@
+package compare
+
+import (
+	"database/sql/driver"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseOrderBy(t *testing.T) {
+	tests := []struct {
+		sql      string
+		expected *OrderBy
+	}{
+		{"select a from t", nil},
+		{"select a from (select a from t order by a) t1", nil},
+		{"select a, row_number() over (order by b) from t", nil},
+		{"select a from t where b = 'order by c'", nil},
+		{"select a from t order by a", &OrderBy{Items: []string{"a"}, Limit: -1}},
+		{"select a, b from t ORDER  BY a DESC, `t`.`b` asc limit 10", &OrderBy{Items: []string{"a", "`t`.`b`"}, Limit: 10}},
+		{"select a from t order by a + 1, concat(b, ',') for update", &OrderBy{Items: []string{"a + 1", "concat(b, ',')"}, Limit: -1}},
+		{"(select a from t1 order by a) union (select a from t2) order by 1 limit 3;", &OrderBy{Items: []string{"1"}, Limit: 3}},
+		{"select a from t where b = 'it\\'s' order by a", &OrderBy{Items: []string{"a"}, Limit: -1}},
+		{"select a from t order by a lock in share mode", &OrderBy{Items: []string{"a"}, Limit: -1}},
+		{"select border, bylaw from t order by border", &OrderBy{Items: []string{"border"}, Limit: -1}},
+		{"select a from t order by a limit 5, 10", &OrderBy{Items: []string{"a"}, Limit: 10, Offset: true}},
+		{"select a from t order by a LIMIT 10 OFFSET 5 for update", &OrderBy{Items: []string{"a"}, Limit: 10, Offset: true}},
+		{"select a from t order by a limit 0,10", &OrderBy{Items: []string{"a"}, Limit: 10}},
+		{"select a from t order by a limit 10 for update", &OrderBy{Items: []string{"a"}, Limit: 10}},
+		{"select a from t order by a limit ?", &OrderBy{Items: []string{"a"}, Limit: -1}},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, ParseOrderBy(test.sql), test.sql)
+	}
+}
+
+func TestKeyColumns(t *testing.T) {
+	header := []string{"a", "b", "a+1", "c"}
+	orderBy := &OrderBy{Items: []string{"`t`.`b`", "a + 1", "1", "4"}}
+	assert.Equal(t, []int{1, 2, 0, 3}, orderBy.KeyColumns(header))
+
+	orderBy = &OrderBy{Items: []string{"b", "d", "a"}}
+	assert.Equal(t, []int{1}, orderBy.KeyColumns(header))
+
+	orderBy = &OrderBy{Items: []string{"5", "a"}}
+	assert.Equal(t, []int{}, orderBy.KeyColumns(header))
+}
+
+// result of rows with two columns
+func pairsOf(cols ...string) *SqlResult {
+	rows := make([][]interface{}, 0)
+	for i := 0; i+1 < len(cols); i += 2 {
+		rows = append(rows, []interface{}{cols[i], cols[i+1]})
+	}
+	return rowsOf(rows...)
+}
+
+func TestPartialOrderEqualTo(t *testing.T) {
+	res1 := pairsOf("1", "a", "1", "b", "2", "c", "3", "d", "3", "e")
+	tied := pairsOf("1", "b", "1", "a", "2", "c", "3", "d", "3", "e")
+	reordered := pairsOf("2", "c", "1", "a", "1", "b", "3", "d", "3", "e")
+	limited := pairsOf("1", "b", "1", "a", "2", "c", "3", "e", "3", "f")
+
+	keys := []int{0}
+	assert.True(t, res1.PartialOrderEqualTo(tied, keys, -1, false, nil))
+	assert.False(t, res1.PartialOrderEqualTo(reordered, keys, -1, false, nil))
+	assert.False(t, res1.PartialOrderEqualTo(limited, keys, -1, false, nil))
+	assert.True(t, res1.PartialOrderEqualTo(limited, keys, 5, false, nil))
+	assert.True(t, res1.PartialOrderEqualTo(limited, keys, 5, false, NewTolerance()))
+	// the last group of a limited result must have the same keys and size
+	assert.False(t, res1.PartialOrderEqualTo(pairsOf("1", "b", "1", "a", "2", "c", "3", "e", "4", "f"),
+		keys, 5, false, nil))
+	assert.False(t, res1.PartialOrderEqualTo(pairsOf("1", "b", "1", "a", "2", "c", "3", "e"),
+		keys, 5, false, nil))
+	// the last group is compared fully if the result is shorter than the LIMIT
+	short := pairsOf("1", "a", "2", "b")
+	assert.False(t, short.PartialOrderEqualTo(pairsOf("1", "a", "2", "WRONG"), keys, 10, false, nil))
+	assert.True(t, short.PartialOrderEqualTo(pairsOf("1", "a", "2", "WRONG"), keys, 2, false, nil))
+	// the first group of a result with an offset can be any rows with the tied keys
+	offset := pairsOf("1", "x", "2", "c", "3", "d", "3", "e")
+	assert.False(t, offset.PartialOrderEqualTo(pairsOf("1", "y", "2", "c", "3", "d", "3", "f"), keys, 4, false, nil))
+	assert.True(t, offset.PartialOrderEqualTo(pairsOf("1", "y", "2", "c", "3", "d", "3", "f"), keys, 4, true, nil))
+	assert.False(t, offset.PartialOrderEqualTo(pairsOf("1", "y", "2", "d", "3", "d", "3", "f"), keys, 4, true, nil))
+	assert.False(t, offset.PartialOrderEqualTo(pairsOf("0", "x", "2", "c", "3", "d", "3", "e"), keys, 4, true, nil))
+	// all columns are keys
+	assert.False(t, res1.PartialOrderEqualTo(tied, []int{0, 1}, -1, false, nil))
+}
+
+func TestByOrderBy(t *testing.T) {
+	sql := "SELECT a, b FROM t ORDER BY a LIMIT 2"
+	db1, mock1, err := sqlmock.New()
+	assert.Equal(t, nil, err)
+	db2, mock2, err := sqlmock.New()
+	assert.Equal(t, nil, err)
+	header := []string{"a", "b"}
+	for i := 0; i < 2; i++ {
+		mock1.ExpectQuery(sql).WillReturnRows(getRows(header, [][]driver.Value{{1, "x"}, {1, "y"}}))
+		mock2.ExpectQuery(sql).WillReturnRows(getRows(header, [][]driver.Value{{1, "z"}, {1, "x"}}))
+	}
+
+	consistent, _, _ := ByQueryWithOptions(sql, db1, db2, &Options{OrderBy: true})
+	assert.True(t, consistent)
+	consistent, _, _ = ByQueryWithOptions(sql, db1, db2, &Options{})
+	assert.False(t, consistent)
+
+	// a result shorter than the LIMIT is not cut, so its last group is compared
+	sql = "SELECT id, v FROM t ORDER BY id LIMIT 10"
+	header = []string{"id", "v"}
+	mock1.ExpectQuery(sql).WillReturnRows(getRows(header, [][]driver.Value{{1, "a"}, {2, "b"}}))
+	mock2.ExpectQuery(sql).WillReturnRows(getRows(header, [][]driver.Value{{1, "a"}, {2, "WRONG"}}))
+	consistent, _, _ = ByQueryWithOptions(sql, db1, db2, &Options{OrderBy: true})
+	assert.False(t, consistent)
+}
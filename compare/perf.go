@@ -0,0 +1,174 @@
+package compare
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ElapsedOf returns the time of running the statement of res,
+// 0 if res is not timed
+func ElapsedOf(res DsnRes) time.Duration {
+	switch r := res.(type) {
+	case *QueryDsnRes:
+		return r.Elapsed
+	case *execDsnRes:
+		return r.elapsed
+	}
+	return 0
+}
+
+// Perf is the rules of detecting latency regressions between two dsns
+type Perf struct {
+	// statements whose slower latency is Ratio times the faster one are regressions
+	Ratio float64
+	// queries slower than Slow in either dsn are run Repeat more times and
+	// the median latencies are compared, statements faster than Slow in both
+	// dsns are never regressions because their latencies are mostly noise
+	Slow   time.Duration
+	Repeat int
+}
+
+// Latency is the latencies of a statement in two dsns
+type Latency struct {
+	Sql      string
+	Latency1 time.Duration
+	Latency2 time.Duration
+	// times the statement ran in each dsn
+	Runs int
+}
+
+// Ratio returns the latency of dsn2 divided by the latency of dsn1
+func (l *Latency) Ratio() float64 {
+	if l.Latency1 <= 0 {
+		if l.Latency2 <= 0 {
+			return 1
+		}
+		return math.Inf(1)
+	}
+	return float64(l.Latency2) / float64(l.Latency1)
+}
+
+func (l *Latency) String() string {
+	return fmt.Sprintf("dsn1: %v, dsn2: %v, ratio: %.2f, runs: %d",
+		l.Latency1, l.Latency2, l.Ratio(), l.Runs)
+}
+
+// Regressed returns whether the latencies of two dsns differ more than Ratio
+func (p *Perf) Regressed(l *Latency) bool {
+	if l.Latency1 < p.Slow && l.Latency2 < p.Slow {
+		return false
+	}
+	ratio := l.Ratio()
+	return ratio > p.Ratio || ratio*p.Ratio < 1
+}
+
+// Measure returns the latencies of sql from its results in two dbs, a slow query
+// is repeated to take the median latencies. Nil is returned if either result is
+// an error, whose latency is meaningless
+func (p *Perf) Measure(sql string, db1 *sql.DB, db2 *sql.DB, dsn1Res DsnRes, dsn2Res DsnRes,
+	opts *Options) *Latency {
+	if dsn1Res.Err() != nil || dsn2Res.Err() != nil {
+		return nil
+	}
+
+	latencies1 := []time.Duration{ElapsedOf(dsn1Res)}
+	latencies2 := []time.Duration{ElapsedOf(dsn2Res)}
+	// other sqls may change data, so only read-only queries can be repeated
+	if isReadOnly(sql) && (latencies1[0] >= p.Slow || latencies2[0] >= p.Slow) {
+		for i := 0; i < p.Repeat; i++ {
+			var res1 *QueryDsnRes
+			var res2 *QueryDsnRes
+
+			wg := &sync.WaitGroup{}
+			wg.Add(2)
+
+			go func() {
+				res1 = newQueryDsnRes(db1, sql, opts)
+				wg.Done()
+			}()
+
+			go func() {
+				res2 = newQueryDsnRes(db2, sql, opts)
+				wg.Done()
+			}()
+
+			wg.Wait()
+
+			if res1.err != nil || res2.err != nil {
+				break
+			}
+			latencies1 = append(latencies1, res1.Elapsed)
+			latencies2 = append(latencies2, res2.Elapsed)
+		}
+	}
+
+	return &Latency{
+		Sql:      sql,
+		Latency1: median(latencies1),
+		Latency2: median(latencies2),
+		Runs:     len(latencies1),
+	}
+}
+
+func median(latencies []time.Duration) time.Duration {
+	sorted := append([]time.Duration{}, latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[len(sorted)/2]
+}
+
+// percentile returns the nearest rank percentile of sorted latencies
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
+
+// LatencyStats collects latencies of statements in two dsns
+type LatencyStats struct {
+	latencies1 []time.Duration
+	latencies2 []time.Duration
+	regressed  int
+}
+
+// Add collects l, whether it is regressed or not
+func (s *LatencyStats) Add(l *Latency, regressed bool) {
+	s.latencies1 = append(s.latencies1, l.Latency1)
+	s.latencies2 = append(s.latencies2, l.Latency2)
+	if regressed {
+		s.regressed++
+	}
+}
+
+// Percentile returns the p-th percentile latencies of two dsns
+func (s *LatencyStats) Percentile(p float64) (time.Duration, time.Duration) {
+	sorted1 := append([]time.Duration{}, s.latencies1...)
+	sorted2 := append([]time.Duration{}, s.latencies2...)
+	sort.Slice(sorted1, func(i, j int) bool { return sorted1[i] < sorted1[j] })
+	sort.Slice(sorted2, func(i, j int) bool { return sorted2[i] < sorted2[j] })
+	return percentile(sorted1, p), percentile(sorted2, p)
+}
+
+// String returns a table of latency percentiles
+func (s *LatencyStats) String() string {
+	lines := []string{fmt.Sprintf("%-6s %14s %14s %8s", "", "dsn1", "dsn2", "ratio")}
+	for _, p := range []struct {
+		name string
+		p    float64
+	}{{"p50", 50}, {"p90", 90}, {"p99", 99}, {"max", 100}} {
+		l1, l2 := s.Percentile(p.p)
+		l := &Latency{Latency1: l1, Latency2: l2}
+		lines = append(lines, fmt.Sprintf("%-6s %14v %14v %8.2f", p.name, l1, l2, l.Ratio()))
+	}
+	lines = append(lines, fmt.Sprintf("statements: %d, regressions: %d", len(s.latencies1), s.regressed))
+	return strings.Join(lines, "\n")
+}
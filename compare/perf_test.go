@@ -0,0 +1,81 @@
+package compare
+
+import (
+	"database/sql/driver"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestPerfRegressed(t *testing.T) {
+	perf := &Perf{Ratio: 2, Slow: 100 * time.Millisecond}
+	ms := time.Millisecond
+
+	assert.False(t, perf.Regressed(&Latency{Latency1: 1 * ms, Latency2: 50 * ms}))
+	assert.False(t, perf.Regressed(&Latency{Latency1: 100 * ms, Latency2: 150 * ms}))
+	assert.True(t, perf.Regressed(&Latency{Latency1: 100 * ms, Latency2: 250 * ms}))
+	assert.True(t, perf.Regressed(&Latency{Latency1: 250 * ms, Latency2: 100 * ms}))
+	assert.True(t, perf.Regressed(&Latency{Latency1: 0, Latency2: 100 * ms}))
+	assert.Equal(t, 2.5, (&Latency{Latency1: 100 * ms, Latency2: 250 * ms}).Ratio())
+	assert.Equal(t, 1.0, (&Latency{}).Ratio())
+}
+
+func TestPerfMeasure(t *testing.T) {
+	sql := "SELECT a FROM t"
+	db1, mock1, err := sqlmock.New()
+	assert.Equal(t, nil, err)
+	db2, mock2, err := sqlmock.New()
+	assert.Equal(t, nil, err)
+	for i := 0; i < 3; i++ {
+		mock1.ExpectQuery(sql).WillReturnRows(getRows([]string{"a"}, [][]driver.Value{{1}}))
+		mock2.ExpectQuery(sql).WillDelayFor(50 * time.Millisecond).
+			WillReturnRows(getRows([]string{"a"}, [][]driver.Value{{1}}))
+	}
+
+	perf := &Perf{Ratio: 2, Slow: 20 * time.Millisecond, Repeat: 2}
+	opts := &Options{}
+	_, res1, res2 := ByQueryWithOptions(sql, db1, db2, opts)
+	assert.True(t, ElapsedOf(res2) >= 50*time.Millisecond)
+
+	latency := perf.Measure(sql, db1, db2, res1, res2, opts)
+	assert.Equal(t, 3, latency.Runs)
+	assert.True(t, latency.Latency2 >= 50*time.Millisecond)
+	assert.True(t, perf.Regressed(latency))
+	assert.Equal(t, nil, mock1.ExpectationsWereMet())
+	assert.Equal(t, nil, mock2.ExpectationsWereMet())
+
+	// fast sqls are not repeated, and errors are not timed
+	latency = perf.Measure(sql, db1, db2, &QueryDsnRes{Elapsed: time.Millisecond}, &QueryDsnRes{}, opts)
+	assert.Equal(t, 1, latency.Runs)
+	assert.Nil(t, perf.Measure(sql, db1, db2, &QueryDsnRes{err: errors.New("mock")}, &QueryDsnRes{}, opts))
+
+	// sqls which may change data are not repeated
+	slow := &QueryDsnRes{Elapsed: time.Second}
+	latency = perf.Measure("INSERT INTO t SELECT * FROM t", db1, db2, slow, slow, opts)
+	assert.Equal(t, 1, latency.Runs)
+	assert.Equal(t, nil, mock1.ExpectationsWereMet())
+}
+
+func TestLatencyStats(t *testing.T) {
+	stats := &LatencyStats{}
+	for i := 1; i <= 100; i++ {
+		l := &Latency{Latency1: time.Duration(i) * time.Millisecond, Latency2: time.Duration(2*i) * time.Millisecond}
+		stats.Add(l, i > 90)
+	}
+
+	l1, l2 := stats.Percentile(50)
+	assert.Equal(t, 50*time.Millisecond, l1)
+	assert.Equal(t, 100*time.Millisecond, l2)
+	l1, l2 = stats.Percentile(100)
+	assert.Equal(t, 100*time.Millisecond, l1)
+	assert.Equal(t, 200*time.Millisecond, l2)
+
+	assert.Equal(t, `                 dsn1           dsn2    ratio
+p50              50ms          100ms     2.00
+p90              90ms          180ms     2.00
+p99              99ms          198ms     2.00
+max             100ms          200ms     2.00
+statements: 100, regressions: 10`, stats.String())
+}
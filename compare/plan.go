@@ -0,0 +1,158 @@
+package compare
+
+import (
+	"context"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/hex"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// PlanDsnRes is the plan of a query, see ByPlan
+type PlanDsnRes struct {
+	// raw result of EXPLAIN
+	Res *SqlResult
+	// normalized plan tree without ids and estimates, see PlanShape
+	Shape []string
+	err   error
+}
+
+func (p *PlanDsnRes) Err() error {
+	return p.err
+}
+
+func (p *PlanDsnRes) String() string {
+	return strings.Join(p.Shape, "\n")
+}
+
+// Signature is the short digest of the plan shape, "error" if explain fails
+func (p *PlanDsnRes) Signature() string {
+	if p.err != nil {
+		return "error"
+	}
+	h := sha1.Sum([]byte(p.String()))
+	return hex.EncodeToString(h[:])[:12]
+}
+
+// columns of estimates, costs and details which are not a part of plan shape,
+// for TiDB, MySQL traditional EXPLAIN and EXPLAIN ANALYZE
+var planDetailCols = map[string]bool{
+	"estrows":        true,
+	"actrows":        true,
+	"count":          true,
+	"rows":           true,
+	"filtered":       true,
+	"cost":           true,
+	"key_len":        true,
+	"ref":            true,
+	"partitions":     true,
+	"possible_keys":  true,
+	"operator info":  true,
+	"execution info": true,
+	"memory":         true,
+	"disk":           true,
+}
+
+var (
+	// TableReader_7 -> TableReader
+	planIdSuffix = regexp.MustCompile(`_\d+\b`)
+	// (cost=0.35 rows=1) and (actual time=...) of FORMAT=TREE
+	planEstimates = regexp.MustCompile(`\s*\((cost|rows|actual)[^)]*\)`)
+	// <derived2> -> <derived>
+	planTableIds = regexp.MustCompile(`<([a-z_]+)[\d,]+>`)
+	planNumber   = regexp.MustCompile(`^\d+$`)
+)
+
+// PlanShape normalizes the result of EXPLAIN into lines of plan tree, operator
+// ids, select ids, estimates and operator details are stripped, so only the
+// operators, tasks, access objects and join orders are left
+func PlanShape(res *SqlResult) []string {
+	shape := make([]string, 0, len(res.Data))
+	for _, row := range res.Data {
+		parts := make([]string, 0, len(row))
+		for c, col := range row {
+			name := ""
+			if c < len(res.Header) {
+				name = strings.ToLower(res.Header[c])
+			}
+			if planDetailCols[name] {
+				continue
+			}
+
+			value := "NULL"
+			if col != nil {
+				value = string(col)
+			}
+			if name == "id" {
+				if planNumber.MatchString(value) {
+					continue
+				}
+				value = planIdSuffix.ReplaceAllString(value, "")
+			}
+			value = planTableIds.ReplaceAllString(value, "<$1>")
+			if value = planEstimates.ReplaceAllString(value, ""); value != "" {
+				parts = append(parts, value)
+			}
+		}
+
+		// FORMAT=TREE returns the whole tree in a value
+		for _, line := range strings.Split(strings.Join(parts, " | "), "\n") {
+			if strings.TrimSpace(line) != "" {
+				shape = append(shape, strings.TrimRight(line, " "))
+			}
+		}
+	}
+	return shape
+}
+
+func newPlanDsnRes(db *sql.DB, sql string, explain string, opts *Options) *PlanDsnRes {
+	res := &PlanDsnRes{}
+	_, _, res.err = opts.run(db, func(ctx context.Context, s Session) (err error) {
+		res.Res, err = query(ctx, s, explain+" "+sql)
+		return err
+	})
+	if res.err == nil {
+		res.Shape = PlanShape(res.Res)
+	}
+	return res
+}
+
+// ByPlan explains the query in two dbs by the explain statement like `EXPLAIN`
+// or `EXPLAIN FORMAT='brief'`, and compares their plan shapes. Sqls which are not
+// read-only queries are executed in both dbs without comparing, since explaining
+// them does not change data, see isReadOnly
+func ByPlan(sql string, db1 *sql.DB, db2 *sql.DB, explain string, opts *Options) (consistent bool,
+	dsn1Res DsnRes, dsn2Res DsnRes) {
+	if !isReadOnly(sql) {
+		_, dsn1Res, dsn2Res = ByExecWithOptions(sql, db1, db2, opts)
+		return true, dsn1Res, dsn2Res
+	}
+
+	var res1 *PlanDsnRes
+	var res2 *PlanDsnRes
+
+	wg := &sync.WaitGroup{}
+	wg.Add(2)
+
+	go func() {
+		res1 = newPlanDsnRes(db1, sql, explain, opts)
+		wg.Done()
+	}()
+
+	go func() {
+		res2 = newPlanDsnRes(db2, sql, explain, opts)
+		wg.Done()
+	}()
+
+	wg.Wait()
+
+	if !opts.ErrCodes.Consistent(res1.err, res2.err) {
+		return false, res1, res2
+	}
+	if res1.err != nil || res2.err != nil {
+		return true, res1, res2
+	}
+	return res1.Signature() == res2.Signature(), res1, res2
+}
@@ -0,0 +1,104 @@
+package compare
+
+import (
+	"database/sql/driver"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPlanShape(t *testing.T) {
+	tidb := &SqlResult{
+		Header: []string{"id", "estRows", "task", "access object", "operator info"},
+		Data: rowsOf(
+			[]interface{}{"Projection_4", "10.00", "root", "", "test.t.a"},
+			[]interface{}{"└─IndexReader_7", "10.00", "root", "", "index:IndexRangeScan_6"},
+			[]interface{}{"  └─IndexRangeScan_6", "10.00", "cop[tikv]", "table:t_1, index:idx_a(a)", "range:[1,1]"},
+		).Data,
+	}
+	assert.Equal(t, []string{
+		"Projection | root",
+		"└─IndexReader | root",
+		"  └─IndexRangeScan | cop[tikv] | table:t_1, index:idx_a(a)",
+	}, PlanShape(tidb))
+
+	mysql := &SqlResult{
+		Header: []string{"id", "select_type", "table", "partitions", "type", "possible_keys",
+			"key", "key_len", "ref", "rows", "filtered", "Extra"},
+		Data: rowsOf(
+			[]interface{}{"1", "PRIMARY", "<derived2>", nil, "ALL", nil, nil, nil, nil, "2", "100.00", nil},
+			[]interface{}{"2", "DERIVED", "t", nil, "ref", "idx_a", "idx_a", "5", "const", "1", "100.00", "Using index"},
+		).Data,
+	}
+	assert.Equal(t, []string{
+		"PRIMARY | <derived> | ALL | NULL | NULL",
+		"DERIVED | t | ref | idx_a | Using index",
+	}, PlanShape(mysql))
+
+	tree := &SqlResult{
+		Header: []string{"EXPLAIN"},
+		Data: rowsOf([]interface{}{"-> Filter: (t.a > 1)  (cost=0.35 rows=1)\n" +
+			"    -> Table scan on t  (cost=0.35 rows=1)\n"}).Data,
+	}
+	assert.Equal(t, []string{"-> Filter: (t.a > 1)", "    -> Table scan on t"}, PlanShape(tree))
+}
+
+func TestIsReadOnly(t *testing.T) {
+	for _, sql := range []string{"SELECT 1", "  select a from t", "WITH t1 AS (SELECT 1) SELECT * FROM t1",
+		"(select a from t1) union (select a from t2)"} {
+		assert.True(t, isReadOnly(sql), sql)
+	}
+	for _, sql := range []string{"INSERT INTO t SELECT * FROM t1", "REPLACE INTO t VALUES (1)", "DROP TABLE t",
+		"ALTER TABLE t ADD INDEX (a)", "TRUNCATE t", "SET @a = 1", "BEGIN", "COMMIT", "selectx", ""} {
+		assert.False(t, isReadOnly(sql), sql)
+	}
+}
+
+func TestByPlan(t *testing.T) {
+	sql := "SELECT a FROM t WHERE a = 1"
+	db1, mock1, err := sqlmock.New()
+	assert.Equal(t, nil, err)
+	db2, mock2, err := sqlmock.New()
+	assert.Equal(t, nil, err)
+	header := []string{"id", "estRows", "task", "access object", "operator info"}
+	explain := `EXPLAIN FORMAT='brief' SELECT a FROM t WHERE a = 1`
+	mock1.ExpectQuery(explain).WillReturnRows(getRows(header, [][]driver.Value{
+		{"IndexReader_6", "10.00", "root", "", "index:IndexRangeScan_5"},
+		{"└─IndexRangeScan_5", "10.00", "cop[tikv]", "table:t, index:a(a)", "range:[1,1]"},
+	}))
+	mock2.ExpectQuery(explain).WillReturnRows(getRows(header, [][]driver.Value{
+		{"IndexReader_7", "8.00", "root", "", "index:IndexRangeScan_6"},
+		{"└─IndexRangeScan_6", "8.00", "cop[tikv]", "table:t, index:a(a)", "range:[1,1]"},
+	}))
+	mock1.ExpectQuery(explain).WillReturnRows(getRows(header, [][]driver.Value{
+		{"IndexReader_6", "10.00", "root", "", "index:IndexRangeScan_5"},
+		{"└─IndexRangeScan_5", "10.00", "cop[tikv]", "table:t, index:a(a)", "range:[1,1]"},
+	}))
+	mock2.ExpectQuery(explain).WillReturnRows(getRows(header, [][]driver.Value{
+		{"TableReader_7", "8.00", "root", "", "data:Selection_6"},
+		{"└─Selection_6", "8.00", "cop[tikv]", "", "eq(test.t.a, 1)"},
+		{"  └─TableFullScan_5", "10000.00", "cop[tikv]", "table:t", "keep order:false"},
+	}))
+	mock1.ExpectExec("UPDATE t SET a = 2").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock2.ExpectExec("UPDATE t SET a = 2").WillReturnResult(sqlmock.NewResult(0, 2))
+	mock1.ExpectExec("INSERT INTO t VALUES \\(1\\)").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock2.ExpectExec("INSERT INTO t VALUES \\(1\\)").WillReturnResult(sqlmock.NewResult(1, 1))
+
+	opts := &Options{}
+	consistent, _, _ := ByPlan(sql, db1, db2, "EXPLAIN FORMAT='brief'", opts)
+	assert.True(t, consistent)
+
+	consistent, res1, res2 := ByPlan(sql, db1, db2, "EXPLAIN FORMAT='brief'", opts)
+	assert.False(t, consistent)
+	assert.NotEqual(t, res1.(*PlanDsnRes).Signature(), res2.(*PlanDsnRes).Signature())
+	assert.Equal(t, "TableReader | root\n└─Selection | cop[tikv]\n  └─TableFullScan | cop[tikv] | table:t",
+		res2.String())
+
+	consistent, _, _ = ByPlan("UPDATE t SET a = 2", db1, db2, "EXPLAIN", opts)
+	assert.True(t, consistent)
+	// statements which are not read-only are executed instead of explained
+	consistent, _, _ = ByPlan("INSERT INTO t VALUES (1)", db1, db2, "EXPLAIN", opts)
+	assert.True(t, consistent)
+	assert.Equal(t, nil, mock1.ExpectationsWereMet())
+	assert.Equal(t, nil, mock2.ExpectationsWereMet())
+}
@@ -0,0 +1,67 @@
+package compare
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Column is the schema of a result column, a property is
+// compared only if both sides know it
+type Column struct {
+	Name        string
+	Type        string
+	Nullable    bool
+	HasNullable bool
+	Precision   int64
+	Scale       int64
+	HasDecimal  bool
+}
+
+// Schema returns the schema of result by its ColumnTypes, the length of
+// columns is not known because the mysql driver does not return it
+func (s *SqlResult) Schema() []*Column {
+	if s.schema != nil {
+		return s.schema
+	}
+
+	s.schema = make([]*Column, len(s.ColumnTypes))
+	for i, ct := range s.ColumnTypes {
+		col := &Column{Name: ct.Name(), Type: ct.DatabaseTypeName()}
+		col.Nullable, col.HasNullable = ct.Nullable()
+		col.Precision, col.Scale, col.HasDecimal = ct.DecimalSize()
+		s.schema[i] = col
+	}
+	return s.schema
+}
+
+// SchemaDiffs returns the differences of two result schemas,
+// like "column 1 `a`: type BIGINT vs DECIMAL"
+func SchemaDiffs(schema1 []*Column, schema2 []*Column) []string {
+	if len(schema1) != len(schema2) {
+		return []string{fmt.Sprintf("column count: %d vs %d", len(schema1), len(schema2))}
+	}
+
+	diffs := make([]string, 0)
+	for i := range schema1 {
+		col1, col2 := schema1[i], schema2[i]
+		props := make([]string, 0)
+		if col1.Name != col2.Name {
+			props = append(props, fmt.Sprintf("name %s vs %s", col1.Name, col2.Name))
+		}
+		if col1.Type != "" && col2.Type != "" && !strings.EqualFold(col1.Type, col2.Type) {
+			props = append(props, fmt.Sprintf("type %s vs %s", col1.Type, col2.Type))
+		}
+		if col1.HasNullable && col2.HasNullable && col1.Nullable != col2.Nullable {
+			props = append(props, fmt.Sprintf("nullable %t vs %t", col1.Nullable, col2.Nullable))
+		}
+		if col1.HasDecimal && col2.HasDecimal &&
+			(col1.Precision != col2.Precision || col1.Scale != col2.Scale) {
+			props = append(props, fmt.Sprintf("decimal(%d,%d) vs decimal(%d,%d)",
+				col1.Precision, col1.Scale, col2.Precision, col2.Scale))
+		}
+		if len(props) > 0 {
+			diffs = append(diffs, fmt.Sprintf("column %d `%s`: %s", i+1, col1.Name, strings.Join(props, ", ")))
+		}
+	}
+	return diffs
+}
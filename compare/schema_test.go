@@ -0,0 +1,40 @@
+package compare
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSchemaDiffs(t *testing.T) {
+	schema1 := []*Column{
+		{Name: "a", Type: "BIGINT", Nullable: true, HasNullable: true},
+		{Name: "b", Type: "DECIMAL", Precision: 10, Scale: 2, HasDecimal: true},
+		{Name: "c", Type: "VARCHAR", Nullable: true},
+		{Name: "d", Type: "INT"},
+	}
+	schema2 := []*Column{
+		{Name: "a", Type: "DECIMAL", Nullable: false, HasNullable: true},
+		{Name: "b", Type: "decimal", Precision: 11, Scale: 2, HasDecimal: true},
+		{Name: "c", Type: "VARCHAR", Nullable: false},
+		{Name: "e", Type: ""},
+	}
+
+	assert.Equal(t, []string{
+		"column 1 `a`: type BIGINT vs DECIMAL, nullable true vs false",
+		"column 2 `b`: decimal(10,2) vs decimal(11,2)",
+		"column 4 `d`: name d vs e",
+	}, SchemaDiffs(schema1, schema2))
+	assert.Equal(t, 0, len(SchemaDiffs(schema1, schema1)))
+	assert.Equal(t, []string{"column count: 4 vs 1"}, SchemaDiffs(schema1, schema2[:1]))
+}
+
+func TestKind(t *testing.T) {
+	res := &SqlResult{Data: [][][]byte{{[]byte("1")}}}
+	assert.Equal(t, KindErr, Kind(&QueryDsnRes{Res: res}, &QueryDsnRes{err: errors.New("mock")}))
+	assert.Equal(t, KindResult, Kind(&QueryDsnRes{Res: res}, &QueryDsnRes{Res: res}))
+	diffs := []string{"column 1 `a`: type BIGINT vs DECIMAL"}
+	assert.Equal(t, KindSchema, Kind(&QueryDsnRes{Res: res, SchemaDiffs: diffs},
+		&QueryDsnRes{Res: res, SchemaDiffs: diffs}))
+	assert.Equal(t, KindResult, Kind(&execDsnRes{rowsAffected: 1}, &execDsnRes{rowsAffected: 2}))
+}
@@ -8,9 +8,11 @@ import (
 	_ "github.com/go-sql-driver/mysql"
 	_ "github.com/mattn/go-sqlite3"
 	"log"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
+	"unicode"
 )
 
 // OpenDBWithRetry opens a database specified by its database driver name and a
@@ -50,25 +52,62 @@ var OpenDBWithRetry = func(driverName, dataSourceName string) (mdb *sql.DB, err
 // sql result present one query result receive from database
 type SqlResult struct {
 	// [row][col][content(nil when it is NULL)]
-	Data        [][][]byte
-	Rows        map[string]bool
+	Data [][][]byte
+	// encoded row -> count of the row, see EncodeRow
+	Rows        map[string]int
 	Header      []string
 	ColumnTypes []*sql.ColumnType
-	err         error
+	// database type names and schema of columns, from ColumnTypes
+	typeNames []string
+	schema    []*Column
+	err       error
 }
 
-func (s *SqlResult) Contains(row string) bool {
-	_, ok := s.Rows[row]
-	return ok
+// EncodeRow encodes row unambiguously, every column is encoded as `N` if
+// it is NULL, or `V<length>:<content>` otherwise, so a string 'NULL' is
+// different from NULL and the content can contain any byte
+func EncodeRow(row [][]byte) string {
+	buf := &bytes.Buffer{}
+	for _, col := range row {
+		if col == nil {
+			buf.WriteString("N")
+			continue
+		}
+		buf.WriteString("V" + strconv.Itoa(len(col)) + ":")
+		buf.Write(col)
+	}
+	return buf.String()
+}
+
+// counts of rows, they are built from Data if Rows is not set
+func (s *SqlResult) counts() map[string]int {
+	if s.Rows == nil {
+		s.Rows = make(map[string]int)
+		for _, row := range s.Data {
+			s.Rows[EncodeRow(row)]++
+		}
+	}
+	return s.Rows
+}
+
+func (s *SqlResult) Contains(row [][]byte) bool {
+	return s.counts()[EncodeRow(row)] > 0
 }
 
+// NonOrderEqualTo compares results as multisets, rows are
+// equal only if they appear the same times in both results
 func (s *SqlResult) NonOrderEqualTo(another *SqlResult) bool {
-	if len(s.Rows) != len(another.Rows) {
+	if len(s.Data) != len(another.Data) {
 		return false
 	}
 
-	for row := range another.Rows {
-		if !s.Contains(row) {
+	counts := s.counts()
+	anotherCounts := another.counts()
+	if len(counts) != len(anotherCounts) {
+		return false
+	}
+	for row, count := range anotherCounts {
+		if counts[row] != count {
 			return false
 		}
 	}
@@ -76,6 +115,49 @@ func (s *SqlResult) NonOrderEqualTo(another *SqlResult) bool {
 	return true
 }
 
+// RowDiff is a row which appears different times in two results
+type RowDiff struct {
+	Row    [][]byte
+	Count1 int
+	Count2 int
+}
+
+// like `2 : 1 : ("a", NULL)`, strings are quoted so NULL is not ambiguous
+func (d *RowDiff) String() string {
+	cols := make([]string, len(d.Row))
+	for i, col := range d.Row {
+		if col == nil {
+			cols[i] = "NULL"
+		} else {
+			cols[i] = strconv.Quote(string(col))
+		}
+	}
+	return fmt.Sprintf("%d : %d : (%s)", d.Count1, d.Count2, strings.Join(cols, ", "))
+}
+
+// RowDiffs returns the rows whose counts in s and another are different,
+// ordered by their first appearances in s and then in another
+func (s *SqlResult) RowDiffs(another *SqlResult) []*RowDiff {
+	counts := s.counts()
+	anotherCounts := another.counts()
+
+	diffs := make([]*RowDiff, 0)
+	visited := make(map[string]bool)
+	for _, data := range [][][][]byte{s.Data, another.Data} {
+		for _, row := range data {
+			key := EncodeRow(row)
+			if visited[key] {
+				continue
+			}
+			visited[key] = true
+			if counts[key] != anotherCounts[key] {
+				diffs = append(diffs, &RowDiff{row, counts[key], anotherCounts[key]})
+			}
+		}
+	}
+	return diffs
+}
+
 // if s is equal to another, will return true
 func (s *SqlResult) BytesEqualTo(another *SqlResult) bool {
 	if len(s.Data) != len(another.Data) {
@@ -191,8 +273,8 @@ func (result *SqlResult) String() string {
 	return strings.Join(lines, "\n")
 }
 
-func query(db *sql.DB, sql string) (*SqlResult, error) {
-	rows, err := db.Query(sql)
+func query(ctx context.Context, s Session, sql string) (*SqlResult, error) {
+	rows, err := s.QueryContext(ctx, sql)
 	if err != nil {
 		return nil, err
 	}
@@ -209,7 +291,7 @@ func query(db *sql.DB, sql string) (*SqlResult, error) {
 	}
 
 	var allRows [][][]byte
-	rowSet := make(map[string]bool)
+	rowCounts := make(map[string]int)
 	for rows.Next() {
 		if rows.Err() != nil {
 			return nil, err
@@ -217,7 +299,6 @@ func query(db *sql.DB, sql string) (*SqlResult, error) {
 
 		var columns = make([][]byte, len(cols))
 		var pointer = make([]interface{}, len(cols))
-		rowStrBuf := &bytes.Buffer{}
 		for i := range columns {
 			pointer[i] = &columns[i]
 		}
@@ -225,25 +306,21 @@ func query(db *sql.DB, sql string) (*SqlResult, error) {
 		if err != nil {
 			return nil, err
 		}
-		for _, colByte := range columns {
-			if colByte == nil {
-				rowStrBuf.WriteString("NULL\t")
-				continue
-			}
-			rowStrBuf.WriteString(string(colByte) + "\t")
-		}
 
-		rowSet[rowStrBuf.String()] = true
+		rowCounts[EncodeRow(columns)]++
 		allRows = append(allRows, columns)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-	return &SqlResult{Data: allRows, Rows: rowSet, Header: cols, ColumnTypes: types}, nil
+	return &SqlResult{Data: allRows, Rows: rowCounts, Header: cols, ColumnTypes: types}, nil
 }
 
-func exec(db *sql.DB, sql string) (int64, error) {
-	result, err := db.Exec(sql)
+func exec(ctx context.Context, s Session, sql string) (int64, error) {
+	result, err := s.ExecContext(ctx, sql)
 	if err != nil {
-		return 0, nil
+		return 0, err
 	}
 
 	rowsAffected, err := result.RowsAffected()
@@ -270,37 +347,95 @@ func isExec(sql string) bool {
 	return ok
 }
 
+// isReadOnly returns whether sql is a query which does not change data,
+// it starts with SELECT, WITH or a parenthesis
+func isReadOnly(sql string) bool {
+	sql = strings.TrimLeftFunc(sql, unicode.IsSpace)
+	return strings.HasPrefix(sql, "(") || hasKeywords(sql, "select") || hasKeywords(sql, "with")
+}
+
 type SqlExecErr struct {
 	sql string
 	err error
 }
 
 func ExecSqlsInDbs(sqls []string, dbs ...*sql.DB) (string, error) {
+	return ExecSqlGroupsInDbs([][]string{sqls}, 1, nil, dbs...)
+}
+
+// Progress is notified after every successful statement,
+// done and total count the statements of all dbs
+type Progress func(done int, total int)
+
+// ExecSqlGroupsInDbs executes every group of sqls in all dbs. Sqls in
+// one group are executed in order, while at most parallel groups are
+// executed concurrently in one db. It stops at the first error and returns
+// the failed sql. progress can be nil
+func ExecSqlGroupsInDbs(groups [][]string, parallel int, progress Progress,
+	dbs ...*sql.DB) (string, error) {
+	if parallel <= 0 {
+		parallel = 1
+	}
+
+	total := 0
+	for _, group := range groups {
+		total += len(group)
+	}
+	total *= len(dbs)
+
+	done := 0
+	progressMutex := &sync.Mutex{}
+	report := func() {
+		if progress == nil {
+			return
+		}
+		progressMutex.Lock()
+		done++
+		progress(done, total)
+		progressMutex.Unlock()
+	}
+
 	wg := &sync.WaitGroup{}
-	wg.Add(len(dbs))
 
 	errCh := make(chan *SqlExecErr, 1)
 	c, cancel := context.WithCancel(context.Background())
-
-	for _, db := range dbs {
-		go func(db *sql.DB) {
-			defer wg.Done()
-			for _, sqlStr := range sqls {
+	defer cancel()
+
+	execGroup := func(db *sql.DB, group []string) {
+		for _, sqlStr := range group {
+			select {
+			case <-c.Done():
+				return
+			default:
+			}
+			if _, err := db.Exec(sqlStr); err != nil {
+				cancel()
 				select {
-				case <-c.Done():
-					break
+				case errCh <- &SqlExecErr{sqlStr, err}:
 				default:
-					if _, err := db.Exec(sqlStr); err != nil {
-						cancel()
-						select {
-						case errCh <- &SqlExecErr{sqlStr, err}:
-						default:
-						}
-						break
-					}
 				}
+				return
 			}
-		}(db)
+			report()
+		}
+	}
+
+	for _, db := range dbs {
+		groupCh := make(chan []string, len(groups))
+		for _, group := range groups {
+			groupCh <- group
+		}
+		close(groupCh)
+
+		wg.Add(parallel)
+		for i := 0; i < parallel; i++ {
+			go func(db *sql.DB) {
+				defer wg.Done()
+				for group := range groupCh {
+					execGroup(db, group)
+				}
+			}(db)
+		}
 	}
 
 	wg.Wait()
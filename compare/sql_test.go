@@ -1,6 +1,7 @@
 package compare
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"github.com/DATA-DOG/go-sqlmock"
@@ -57,6 +58,59 @@ func TestSqlResult_BytesEqualTo(t *testing.T) {
 	assert.Equal(t, false, mockRes1.BytesEqualTo(mockRes3))
 }
 
+func rowsOf(rows ...[]interface{}) *SqlResult {
+	data := make([][][]byte, len(rows))
+	for i, row := range rows {
+		data[i] = make([][]byte, len(row))
+		for j, col := range row {
+			if col != nil {
+				data[i][j] = []byte(col.(string))
+			}
+		}
+	}
+	return &SqlResult{Data: data}
+}
+
+func TestSqlResult_NonOrderEqualTo(t *testing.T) {
+	r := func(cols ...interface{}) []interface{} {
+		return cols
+	}
+
+	// multiplicity
+	assert.False(t, rowsOf(r("1"), r("1"), r("2")).NonOrderEqualTo(rowsOf(r("1"), r("2"), r("2"))))
+	assert.True(t, rowsOf(r("1"), r("1"), r("2")).NonOrderEqualTo(rowsOf(r("1"), r("2"), r("1"))))
+	// string NULL and NULL
+	assert.False(t, rowsOf(r("NULL")).NonOrderEqualTo(rowsOf(r(nil))))
+	// separators in content
+	assert.False(t, rowsOf(r("a\t", "b")).NonOrderEqualTo(rowsOf(r("a", "\tb"))))
+	assert.False(t, rowsOf(r("", "")).NonOrderEqualTo(rowsOf(r(nil, ""))))
+	assert.True(t, rowsOf().NonOrderEqualTo(rowsOf()))
+
+	assert.Equal(t, "NV4:NULLV0:", EncodeRow([][]byte{nil, []byte("NULL"), {}}))
+	assert.True(t, rowsOf(r("a", nil)).Contains([][]byte{[]byte("a"), nil}))
+	assert.False(t, rowsOf(r("a", nil)).Contains([][]byte{[]byte("a"), []byte("NULL")}))
+}
+
+func TestSqlResult_RowDiffs(t *testing.T) {
+	r := func(cols ...interface{}) []interface{} {
+		return cols
+	}
+
+	res1 := rowsOf(r("1", nil), r("1", nil), r("2", "x"), r("3", "y"))
+	res2 := rowsOf(r("4", "NULL"), r("2", "x"), r("1", nil))
+	diffs := res1.RowDiffs(res2)
+	strs := make([]string, len(diffs))
+	for i, diff := range diffs {
+		strs[i] = diff.String()
+	}
+	assert.Equal(t, []string{
+		`2 : 1 : ("1", NULL)`,
+		`1 : 0 : ("3", "y")`,
+		`0 : 1 : ("4", "NULL")`,
+	}, strs)
+	assert.Equal(t, 0, len(res1.RowDiffs(res1)))
+}
+
 func TestQuery(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	assert.Equal(t, nil, err)
@@ -89,10 +143,10 @@ func TestQuery(t *testing.T) {
 	mock.ExpectQuery(q2).
 		WillReturnRows(mock2Rows)
 
-	r1, err := query(db, q1)
+	r1, err := query(context.Background(), db, q1)
 	assert.Equal(t, nil, err)
 
-	r2, err := query(db, q2)
+	r2, err := query(context.Background(), db, q2)
 	assert.Equal(t, nil, err)
 
 	assert.Equal(t, expected1, r1.String())
@@ -147,8 +201,26 @@ func TestExecSqlsInDbs(t *testing.T) {
 
 }
 
+func TestExecSqlGroupsInDbs(t *testing.T) {
+	groups := [][]string{getSql(3), getSql(2), getSql(4)}
+	expectSqls := make([]string, 0)
+	for _, group := range groups {
+		expectSqls = append(expectSqls, group...)
+	}
 
+	mockdb0 := getMockDb(t, expectSqls)
+	mockdb1 := getMockDb(t, expectSqls)
 
+	var lastDone, lastTotal int
+	// groups are executed in order if parallel is 1
+	_, err := ExecSqlGroupsInDbs(groups, 1, func(done int, total int) {
+		assert.Equal(t, lastDone+1, done)
+		lastDone, lastTotal = done, total
+	}, mockdb0, mockdb1)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 18, lastDone)
+	assert.Equal(t, 18, lastTotal)
+}
 
 func TestQueryMysql(t *testing.T)  {
 	t.SkipNow()
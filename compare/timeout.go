@@ -0,0 +1,97 @@
+package compare
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// ErrTimeout is the error of a statement which runs longer than
+// Options.Timeout, timeouts are only consistent with timeouts
+var ErrTimeout = errors.New("statement timeout")
+
+// timeout of sending KILL QUERY
+const killTimeout = 10 * time.Second
+
+// ConnectionID returns the id of the connection of session
+func ConnectionID(ctx context.Context, s Session) (int64, error) {
+	rows, err := s.QueryContext(ctx, "SELECT CONNECTION_ID()")
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var id int64
+	if rows.Next() {
+		err = rows.Scan(&id)
+	}
+	if err == nil {
+		err = rows.Err()
+	}
+	return id, err
+}
+
+// KillQuery kills the statement running in connection id by
+// a side connection of db
+func KillQuery(db *sql.DB, id int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), killTimeout)
+	defer cancel()
+	_, err := db.ExecContext(ctx, fmt.Sprintf("KILL QUERY %d", id))
+	return err
+}
+
+// run f on a connection of db and returns the time of running f, f is canceled
+// after Timeout and its statement is killed by a side connection, then ErrTimeout
+// is returned. Warnings are shown after f on the same connection if Warnings is set
+func (o *Options) run(db *sql.DB, f func(ctx context.Context, s Session) error) ([]*Warning, time.Duration, error) {
+	ctx := context.Background()
+	if o.Timeout <= 0 && !o.Warnings {
+		start := time.Now()
+		err := f(ctx, db)
+		return nil, time.Since(start), err
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		log.Printf("Error: get connection error, %v \n", err)
+		start := time.Now()
+		err = f(ctx, db)
+		return nil, time.Since(start), err
+	}
+	defer conn.Close()
+
+	stmtCtx := ctx
+	var id int64
+	if o.Timeout > 0 {
+		if id, err = ConnectionID(ctx, conn); err != nil {
+			log.Printf("Error: get connection id error, %v \n", err)
+		}
+		var cancel context.CancelFunc
+		stmtCtx, cancel = context.WithTimeout(ctx, o.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err = f(stmtCtx, conn)
+	elapsed := time.Since(start)
+	if err != nil && stmtCtx.Err() == context.DeadlineExceeded {
+		if id != 0 {
+			if err := KillQuery(db, id); err != nil {
+				log.Printf("Error: kill query of connection %d error, %v \n", id, err)
+			}
+		}
+		return nil, elapsed, ErrTimeout
+	}
+
+	if !o.Warnings {
+		return nil, elapsed, err
+	}
+	warnings, showErr := ShowWarnings(ctx, conn)
+	if showErr != nil {
+		log.Printf("Error: show warnings error, %v \n", showErr)
+	}
+	return warnings, elapsed, err
+}
@@ -0,0 +1,41 @@
+package compare
+
+import (
+	"database/sql/driver"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestTimeoutConsistent(t *testing.T) {
+	var codes *ErrCodes
+	assert.True(t, codes.Consistent(ErrTimeout, ErrTimeout))
+	assert.False(t, codes.Consistent(ErrTimeout, nil))
+	assert.False(t, codes.Consistent(errors.New("mock"), ErrTimeout))
+	assert.Equal(t, KindTimeout, Kind(&QueryDsnRes{}, &QueryDsnRes{err: ErrTimeout}))
+}
+
+func TestByTimeout(t *testing.T) {
+	sql := "SELECT a FROM t"
+	db1, mock1, err := sqlmock.New()
+	assert.Equal(t, nil, err)
+	db2, mock2, err := sqlmock.New()
+	assert.Equal(t, nil, err)
+	connID := `SELECT CONNECTION_ID\(\)`
+	mock1.ExpectQuery(connID).WillReturnRows(getRows([]string{"id"}, [][]driver.Value{{7}}))
+	mock1.ExpectQuery(sql).WillDelayFor(time.Second).
+		WillReturnRows(getRows([]string{"a"}, [][]driver.Value{{1}}))
+	mock1.ExpectExec("KILL QUERY 7").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock2.ExpectQuery(connID).WillReturnRows(getRows([]string{"id"}, [][]driver.Value{{8}}))
+	mock2.ExpectQuery(sql).WillReturnRows(getRows([]string{"a"}, [][]driver.Value{{1}}))
+
+	consistent, res1, res2 := ByQueryWithOptions(sql, db1, db2, &Options{Timeout: 50 * time.Millisecond})
+	assert.False(t, consistent)
+	assert.Equal(t, ErrTimeout, res1.Err())
+	assert.Equal(t, nil, res2.Err())
+	assert.Equal(t, KindTimeout, Kind(res1, res2))
+	assert.Equal(t, nil, mock1.ExpectationsWereMet())
+	assert.Equal(t, nil, mock2.ExpectationsWereMet())
+}
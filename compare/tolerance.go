@@ -0,0 +1,275 @@
+package compare
+
+import (
+	"bytes"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Tolerance is the rules of comparing values by column types,
+// values of other types or unknown types are compared by bytes
+type Tolerance struct {
+	// floats are equal if their difference is at most FloatAbs,
+	// or at most FloatRel times the larger absolute value
+	FloatRel float64
+	FloatAbs float64
+	// compare decimals by value, like 1.0 and 1, -0 and 0
+	Decimal bool
+	// fractional seconds of temporals are truncated to TemporalFsp
+	// digits before comparing, negative means not normalized
+	TemporalFsp int
+	// compare strings case insensitively, for ci collations
+	CaseInsensitive bool
+}
+
+// NewTolerance returns the tolerance which compares bytes
+func NewTolerance() *Tolerance {
+	return &Tolerance{TemporalFsp: -1}
+}
+
+const (
+	kindOther = iota
+	kindFloat
+	kindDecimal
+	kindTemporal
+	kindString
+)
+
+// kinds of database type names
+var typeKinds = map[string]int{
+	"FLOAT":      kindFloat,
+	"DOUBLE":     kindFloat,
+	"REAL":       kindFloat,
+	"DECIMAL":    kindDecimal,
+	"NUMERIC":    kindDecimal,
+	"DATETIME":   kindTemporal,
+	"TIMESTAMP":  kindTemporal,
+	"TIME":       kindTemporal,
+	"CHAR":       kindString,
+	"VARCHAR":    kindString,
+	"TEXT":       kindString,
+	"TINYTEXT":   kindString,
+	"MEDIUMTEXT": kindString,
+	"LONGTEXT":   kindString,
+	"ENUM":       kindString,
+	"SET":        kindString,
+}
+
+// kinds of columns of s by its ColumnTypes
+func (s *SqlResult) kinds() []int {
+	if s.typeNames == nil {
+		s.typeNames = make([]string, len(s.ColumnTypes))
+		for i, ct := range s.ColumnTypes {
+			s.typeNames[i] = ct.DatabaseTypeName()
+		}
+	}
+	kinds := make([]int, len(s.typeNames))
+	for i, name := range s.typeNames {
+		kinds[i] = typeKinds[strings.ToUpper(name)]
+	}
+	return kinds
+}
+
+// kind of column c, use the one of another if s does not know it
+func colKind(kinds1 []int, kinds2 []int, c int) int {
+	if c < len(kinds1) && kinds1[c] != kindOther {
+		return kinds1[c]
+	}
+	if c < len(kinds2) {
+		return kinds2[c]
+	}
+	return kindOther
+}
+
+// normalize col by its kind, floats are compared by valueEqual
+func (t *Tolerance) normalize(kind int, col []byte) []byte {
+	if col == nil {
+		return nil
+	}
+	switch kind {
+	case kindDecimal:
+		if t.Decimal {
+			return []byte(normalizeDecimal(string(col)))
+		}
+	case kindTemporal:
+		if t.TemporalFsp >= 0 {
+			return []byte(truncateFsp(string(col), t.TemporalFsp))
+		}
+	case kindString:
+		if t.CaseInsensitive {
+			return bytes.ToLower(col)
+		}
+	}
+	return col
+}
+
+// 001.500 -> 1.5, -0.0 -> 0
+func normalizeDecimal(d string) string {
+	neg := strings.HasPrefix(d, "-")
+	d = strings.TrimLeft(strings.TrimPrefix(strings.TrimPrefix(d, "-"), "+"), "0")
+	if strings.Contains(d, ".") {
+		d = strings.TrimRight(strings.TrimRight(d, "0"), ".")
+	}
+	if d == "" || strings.HasPrefix(d, ".") {
+		d = "0" + d
+	}
+	if neg && d != "0" {
+		d = "-" + d
+	}
+	return d
+}
+
+// truncate or pad fractional seconds of t to fsp digits
+func truncateFsp(t string, fsp int) string {
+	index := strings.LastIndex(t, ".")
+	fraction := ""
+	if index != -1 {
+		t, fraction = t[:index], t[index+1:]
+	}
+	if fsp == 0 {
+		return t
+	}
+	if len(fraction) < fsp {
+		fraction += strings.Repeat("0", fsp-len(fraction))
+	}
+	return t + "." + fraction[:fsp]
+}
+
+func (t *Tolerance) floatEqual(col1 []byte, col2 []byte) bool {
+	f1, err1 := strconv.ParseFloat(string(col1), 64)
+	f2, err2 := strconv.ParseFloat(string(col2), 64)
+	if err1 != nil || err2 != nil {
+		return bytes.Equal(col1, col2)
+	}
+	diff := math.Abs(f1 - f2)
+	return f1 == f2 || diff <= t.FloatAbs ||
+		diff <= t.FloatRel*math.Max(math.Abs(f1), math.Abs(f2))
+}
+
+func (t *Tolerance) valueEqual(kind int, col1 []byte, col2 []byte) bool {
+	if col1 == nil || col2 == nil {
+		return col1 == nil && col2 == nil
+	}
+	if kind == kindFloat {
+		return t.floatEqual(col1, col2)
+	}
+	return bytes.Equal(t.normalize(kind, col1), t.normalize(kind, col2))
+}
+
+func (t *Tolerance) rowEqual(kinds1 []int, kinds2 []int, row1 [][]byte, row2 [][]byte) bool {
+	if len(row1) != len(row2) {
+		return false
+	}
+	for c := range row1 {
+		if !t.valueEqual(colKind(kinds1, kinds2, c), row1[c], row2[c]) {
+			return false
+		}
+	}
+	return true
+}
+
+// EqualTo compares results in order by the tolerance
+func (s *SqlResult) EqualTo(another *SqlResult, t *Tolerance) bool {
+	if len(s.Data) != len(another.Data) {
+		return false
+	}
+
+	kinds1, kinds2 := s.kinds(), another.kinds()
+	for r := range s.Data {
+		if !t.rowEqual(kinds1, kinds2, s.Data[r], another.Data[r]) {
+			return false
+		}
+	}
+	return true
+}
+
+// NonOrderEqualToWith compares results as multisets by the tolerance,
+// rows are sorted by their normalized values and compared in order,
+// if it fails, every row is matched with an unmatched equal one, since
+// floats within tolerance may be sorted differently
+func (s *SqlResult) NonOrderEqualToWith(another *SqlResult, t *Tolerance) bool {
+	if len(s.Data) != len(another.Data) {
+		return false
+	}
+
+	kinds1, kinds2 := s.kinds(), another.kinds()
+	rows1 := t.sortedRows(s.Data, kinds1, kinds2)
+	rows2 := t.sortedRows(another.Data, kinds1, kinds2)
+	inOrder := true
+	for r := range rows1 {
+		if !t.rowEqual(kinds1, kinds2, rows1[r], rows2[r]) {
+			inOrder = false
+			break
+		}
+	}
+	if inOrder {
+		return true
+	}
+
+	matched := make([]bool, len(rows2))
+	for _, row1 := range rows1 {
+		found := false
+		for r, row2 := range rows2 {
+			if !matched[r] && t.rowEqual(kinds1, kinds2, row1, row2) {
+				matched[r] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *Tolerance) sortedRows(data [][][]byte, kinds1 []int, kinds2 []int) [][][]byte {
+	rows := make([][][]byte, len(data))
+	for r, row := range data {
+		rows[r] = make([][]byte, len(row))
+		for c, col := range row {
+			rows[r][c] = t.normalize(colKind(kinds1, kinds2, c), col)
+		}
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		return compareRows(kinds1, kinds2, rows[i], rows[j]) < 0
+	})
+	return rows
+}
+
+// NULL is the smallest, floats are compared by value, others by bytes
+func compareRows(kinds1 []int, kinds2 []int, row1 [][]byte, row2 [][]byte) int {
+	for c := 0; c < len(row1) && c < len(row2); c++ {
+		col1, col2 := row1[c], row2[c]
+		if col1 == nil || col2 == nil {
+			if col1 == nil && col2 == nil {
+				continue
+			}
+			if col1 == nil {
+				return -1
+			}
+			return 1
+		}
+
+		if colKind(kinds1, kinds2, c) == kindFloat {
+			f1, err1 := strconv.ParseFloat(string(col1), 64)
+			f2, err2 := strconv.ParseFloat(string(col2), 64)
+			if err1 == nil && err2 == nil {
+				if f1 < f2 {
+					return -1
+				}
+				if f1 > f2 {
+					return 1
+				}
+				continue
+			}
+		}
+		if cmp := bytes.Compare(col1, col2); cmp != 0 {
+			return cmp
+		}
+	}
+	return len(row1) - len(row2)
+}
@@ -0,0 +1,94 @@
+package compare
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "1.5", normalizeDecimal("001.500"))
+	assert.Equal(t, "0", normalizeDecimal("-0.000"))
+	assert.Equal(t, "0.1", normalizeDecimal(".10"))
+	assert.Equal(t, "-12", normalizeDecimal("-12.0"))
+	assert.Equal(t, "100", normalizeDecimal("100"))
+
+	assert.Equal(t, "2020-01-01 10:00:00.123", truncateFsp("2020-01-01 10:00:00.123456", 3))
+	assert.Equal(t, "2020-01-01 10:00:00.100", truncateFsp("2020-01-01 10:00:00.1", 3))
+	assert.Equal(t, "10:00:00", truncateFsp("10:00:00.999", 0))
+}
+
+func TestTolerance(t *testing.T) {
+	r := func(cols ...interface{}) []interface{} {
+		return cols
+	}
+	withTypes := func(res *SqlResult, types ...string) *SqlResult {
+		res.typeNames = types
+		return res
+	}
+
+	tol := NewTolerance()
+	tol.FloatRel = 1e-9
+	tol.FloatAbs = 1e-12
+	tol.Decimal = true
+	tol.TemporalFsp = 3
+	tol.CaseInsensitive = true
+
+	res1 := withTypes(rowsOf(r("0.30000000000000004", "1.0", "2020-01-01 10:00:00.123456", "abc", "x")),
+		"DOUBLE", "DECIMAL", "DATETIME", "VARCHAR", "INT")
+	res2 := withTypes(rowsOf(r("0.3", "1", "2020-01-01 10:00:00.123", "ABC", "x")),
+		"DOUBLE", "DECIMAL", "DATETIME", "VARCHAR", "INT")
+	assert.True(t, res1.EqualTo(res2, tol))
+	assert.False(t, res1.BytesEqualTo(res2))
+	assert.False(t, res1.EqualTo(res2, NewTolerance()))
+
+	// types of the other result are used if they are unknown
+	res3 := rowsOf(r("0.3", "1", "2020-01-01 10:00:00.123", "ABC", "x"))
+	assert.True(t, res1.EqualTo(res3, tol))
+
+	// other types are compared by bytes
+	res4 := withTypes(rowsOf(r("0.3", "1", "2020-01-01 10:00:00.123", "ABC", "X")),
+		"DOUBLE", "DECIMAL", "DATETIME", "VARCHAR", "INT")
+	assert.False(t, res1.EqualTo(res4, tol))
+
+	// floats out of tolerance
+	res5 := withTypes(rowsOf(r("0.31", "1", "2020-01-01 10:00:00.123", "ABC", "x")),
+		"DOUBLE", "DECIMAL", "DATETIME", "VARCHAR", "INT")
+	assert.False(t, res1.EqualTo(res5, tol))
+
+	// NULL
+	assert.False(t, withTypes(rowsOf(r(nil)), "DECIMAL").EqualTo(withTypes(rowsOf(r("0")), "DECIMAL"), tol))
+	assert.True(t, withTypes(rowsOf(r(nil)), "DOUBLE").EqualTo(withTypes(rowsOf(r(nil)), "DOUBLE"), tol))
+}
+
+func TestNonOrderTolerance(t *testing.T) {
+	r := func(cols ...interface{}) []interface{} {
+		return cols
+	}
+	tol := NewTolerance()
+	tol.FloatRel = 1e-9
+	tol.Decimal = true
+
+	res1 := rowsOf(r("1.0", "0.1"), r("2", "0.30000000000000004"), r("1", "0.1"), r(nil, "10"))
+	res1.typeNames = []string{"DECIMAL", "DOUBLE"}
+	res2 := rowsOf(r(nil, "10.000000000001"), r("1", "0.1"), r("2.00", "0.3"), r("1.000", "0.1"))
+	res2.typeNames = []string{"DECIMAL", "DOUBLE"}
+	assert.True(t, res1.NonOrderEqualToWith(res2, tol))
+	assert.False(t, res1.NonOrderEqualTo(res2))
+
+	// multiplicity
+	res3 := rowsOf(r("1", "0.1"), r("1", "0.1"), r("2", "0.3"), r(nil, "10"))
+	res3.typeNames = []string{"DECIMAL", "DOUBLE"}
+	res4 := rowsOf(r("1", "0.1"), r("2", "0.3"), r("2", "0.3"), r(nil, "10"))
+	res4.typeNames = []string{"DECIMAL", "DOUBLE"}
+	assert.False(t, res3.NonOrderEqualToWith(res4, tol))
+
+	// floats within tolerance are sorted before the other columns
+	res5 := rowsOf(r("0.1000000000000001", "5"), r("0.1", "6"))
+	res5.typeNames = []string{"DOUBLE", "INT"}
+	res6 := rowsOf(r("0.1", "5"), r("0.1000000000000001", "6"))
+	res6.typeNames = []string{"DOUBLE", "INT"}
+	assert.True(t, res5.NonOrderEqualToWith(res6, tol))
+	res7 := rowsOf(r("0.1", "5"), r("0.1000000000000001", "7"))
+	res7.typeNames = []string{"DOUBLE", "INT"}
+	assert.False(t, res5.NonOrderEqualToWith(res7, tol))
+}
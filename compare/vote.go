@@ -0,0 +1,163 @@
+package compare
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"log"
+	"sort"
+	"sync"
+)
+
+// Vote is the results of a sql in N dsns, grouped by consistency
+type Vote struct {
+	Sql     string
+	Results []DsnRes
+	// indexes of dsns with consistent results, larger groups first
+	Groups [][]int
+	// indexes of dsns with ignorable errors, they do not vote
+	Ignored []int
+}
+
+// Consistent returns whether the results of all dsns are consistent
+func (v *Vote) Consistent() bool {
+	return len(v.Groups) <= 1
+}
+
+// Majority returns the group larger than any other group,
+// nil if there is a tie
+func (v *Vote) Majority() []int {
+	if len(v.Groups) == 0 ||
+		(len(v.Groups) > 1 && len(v.Groups[0]) == len(v.Groups[1])) {
+		return nil
+	}
+	return v.Groups[0]
+}
+
+// Outliers returns indexes of voting dsns not in the majority group,
+// all of them if there is no majority
+func (v *Vote) Outliers() []int {
+	majority := v.Majority()
+	skipped := make(map[int]bool)
+	for _, i := range majority {
+		skipped[i] = true
+	}
+	for _, i := range v.Ignored {
+		skipped[i] = true
+	}
+
+	outliers := make([]int, 0)
+	for i := range v.Results {
+		if !skipped[i] {
+			outliers = append(outliers, i)
+		}
+	}
+	return outliers
+}
+
+type VoteVisitor func(vote *Vote) error
+
+func ByDsns(sqls []string, dsns []string, opts *Options, visitor VoteVisitor) error {
+	dbs := make([]*sql.DB, len(dsns))
+	for i, dsn := range dsns {
+		db, err := cache.initDb(dsn)
+		if err != nil {
+			return err
+		}
+		dbs[i] = db
+	}
+
+	return ByDbsWithOptions(sqls, dbs, opts, visitor)
+}
+
+// ByDbsWithOptions executes sqls in N dbs, and visits
+// the votes of sqls whose results are inconsistent
+func ByDbsWithOptions(sqls []string, dbs []*sql.DB, opts *Options, visitor VoteVisitor) error {
+	for _, sql := range sqls {
+		if sql == "" {
+			continue
+		}
+
+		vote := BySqlInDbs(sql, dbs, opts)
+		if !vote.Consistent() {
+			if err := visitor(vote); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// BySqlInDbs executes sql in N dbs concurrently, and groups the results,
+// a result joins a group only if it is consistent with all the members,
+// since tolerances and errors without codes are not transitive.
+// Results with ignorable errors do not vote
+func BySqlInDbs(sql string, dbs []*sql.DB, opts *Options) *Vote {
+	vote := &Vote{Sql: sql, Results: make([]DsnRes, len(dbs))}
+
+	exec := isExec(sql)
+	wg := &sync.WaitGroup{}
+	wg.Add(len(dbs))
+	for i := range dbs {
+		go func(i int) {
+			if exec {
+				vote.Results[i] = newExecDsnRes(dbs[i], sql, opts)
+			} else {
+				vote.Results[i] = newQueryDsnRes(dbs[i], sql, opts)
+			}
+			wg.Done()
+		}(i)
+	}
+	wg.Wait()
+
+	for i, res := range vote.Results {
+		if res.Err() == driver.ErrBadConn {
+			log.Printf("Error: connection to dsn%d error, %v \n", i+1, res.Err())
+		}
+
+		if opts.ErrCodes.ignored(res.Err()) {
+			vote.Ignored = append(vote.Ignored, i)
+			continue
+		}
+
+		joined := false
+		for g, group := range vote.Groups {
+			if opts.consistentWithAll(sql, vote.Results, group, res) {
+				vote.Groups[g] = append(group, i)
+				joined = true
+				break
+			}
+		}
+		if !joined {
+			vote.Groups = append(vote.Groups, []int{i})
+		}
+	}
+
+	sort.SliceStable(vote.Groups, func(i, j int) bool {
+		return len(vote.Groups[i]) > len(vote.Groups[j])
+	})
+	return vote
+}
+
+func (o *Options) consistentWithAll(sql string, results []DsnRes, group []int, res DsnRes) bool {
+	for _, i := range group {
+		if !o.consistent(sql, results[i], res) {
+			return false
+		}
+	}
+	return true
+}
+
+// consistent compares results of two dsns by options without
+// saving differences in them
+func (o *Options) consistent(sql string, r1 DsnRes, r2 DsnRes) bool {
+	switch res1 := r1.(type) {
+	case *QueryDsnRes:
+		c1, c2 := *res1, *r2.(*QueryDsnRes)
+		return o.queryConsistent(sql, &c1, &c2)
+	case *execDsnRes:
+		c1, c2 := *res1, *r2.(*execDsnRes)
+		return o.execConsistent(&c1, &c2)
+	}
+	return false
+}
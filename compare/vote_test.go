@@ -0,0 +1,109 @@
+package compare
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestVote(t *testing.T) {
+	vote := &Vote{Results: make([]DsnRes, 4), Groups: [][]int{{0, 2, 3}, {1}}}
+	assert.False(t, vote.Consistent())
+	assert.Equal(t, []int{0, 2, 3}, vote.Majority())
+	assert.Equal(t, []int{1}, vote.Outliers())
+
+	vote = &Vote{Results: make([]DsnRes, 4), Groups: [][]int{{0, 2}, {1, 3}}}
+	assert.Equal(t, []int(nil), vote.Majority())
+	assert.Equal(t, []int{0, 1, 2, 3}, vote.Outliers())
+
+	vote = &Vote{Results: make([]DsnRes, 2), Groups: [][]int{{0, 1}}}
+	assert.True(t, vote.Consistent())
+	assert.Equal(t, []int{}, vote.Outliers())
+}
+
+func TestByDbs(t *testing.T) {
+	sqls := []string{"SELECT a FROM t", "SELECT b FROM t", "SELECT c FROM t"}
+	results := [][]*sqlmock.Rows{
+		{
+			getRows([]string{"a"}, [][]driver.Value{{1}}),
+			getRows([]string{"a"}, [][]driver.Value{{1}}),
+			getRows([]string{"a"}, [][]driver.Value{{1}}),
+			getRows([]string{"a"}, [][]driver.Value{{1}}),
+		},
+		{
+			getRows([]string{"b"}, [][]driver.Value{{1}}),
+			getRows([]string{"b"}, [][]driver.Value{{2}}),
+			getRows([]string{"b"}, [][]driver.Value{{1}}),
+			getRows([]string{"b"}, [][]driver.Value{{1}}),
+		},
+		{
+			getRows([]string{"c"}, [][]driver.Value{{1}}),
+			nil,
+			getRows([]string{"c"}, [][]driver.Value{{2}}),
+			nil,
+		},
+	}
+
+	dbs := make([]*sql.DB, 4)
+	for i := range dbs {
+		db, mock, err := sqlmock.New()
+		assert.Equal(t, nil, err)
+		dbs[i] = db
+		for s, sql := range sqls {
+			if rows := results[s][i]; rows != nil {
+				mock.ExpectQuery(sql).WillReturnRows(rows)
+			} else {
+				mock.ExpectQuery(sql).WillReturnError(errors.New("mock"))
+			}
+		}
+	}
+
+	votes := make([]*Vote, 0)
+	err := ByDbsWithOptions(sqls, dbs, &Options{}, func(vote *Vote) error {
+		votes = append(votes, vote)
+		return nil
+	})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(votes))
+
+	assert.Equal(t, "SELECT b FROM t", votes[0].Sql)
+	assert.Equal(t, [][]int{{0, 2, 3}, {1}}, votes[0].Groups)
+	assert.Equal(t, []int{1}, votes[0].Outliers())
+
+	assert.Equal(t, "SELECT c FROM t", votes[1].Sql)
+	assert.Equal(t, [][]int{{1, 3}, {0}, {2}}, votes[1].Groups)
+	assert.Equal(t, []int{1, 3}, votes[1].Majority())
+}
+
+func TestVoteIgnoredErrors(t *testing.T) {
+	query := "SELECT a FROM t"
+	results := []*sqlmock.Rows{
+		nil,
+		getRows([]string{"a"}, [][]driver.Value{{1}}),
+		getRows([]string{"a"}, [][]driver.Value{{2}}),
+		getRows([]string{"a"}, [][]driver.Value{{2}}),
+	}
+	dbs := make([]*sql.DB, len(results))
+	for i, rows := range results {
+		db, mock, err := sqlmock.New()
+		assert.Equal(t, nil, err)
+		dbs[i] = db
+		if rows != nil {
+			mock.ExpectQuery(query).WillReturnRows(rows)
+		} else {
+			mock.ExpectQuery(query).WillReturnError(myErr(1205))
+		}
+	}
+
+	codes, err := ParseErrCodes([]byte(`{"ignore": ["1205"]}`))
+	assert.Equal(t, nil, err)
+	// the ignorable error is consistent with any result, but it does not vote
+	vote := BySqlInDbs(query, dbs, &Options{ErrCodes: codes})
+	assert.Equal(t, [][]int{{2, 3}, {1}}, vote.Groups)
+	assert.Equal(t, []int{0}, vote.Ignored)
+	assert.Equal(t, []int{2, 3}, vote.Majority())
+	assert.Equal(t, []int{1}, vote.Outliers())
+}
@@ -0,0 +1,123 @@
+package compare
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// Warning is a row of SHOW WARNINGS
+type Warning struct {
+	Level   string
+	Code    uint16
+	Message string
+}
+
+func (w *Warning) String() string {
+	return fmt.Sprintf("%s %d: %s", w.Level, w.Code, w.Message)
+}
+
+// StmtWarnings are warnings of a statement and their differences
+// from the other dsn, see Options.Warnings
+type StmtWarnings struct {
+	Warnings     []*Warning
+	WarningDiffs []string
+	// the statement is inconsistent only by warnings
+	onlyWarnings bool
+}
+
+// WarningsOf returns the warnings of result, nil if there are no warnings
+func WarningsOf(res DsnRes) *StmtWarnings {
+	switch r := res.(type) {
+	case *QueryDsnRes:
+		return &r.StmtWarnings
+	case *execDsnRes:
+		return &r.StmtWarnings
+	}
+	return nil
+}
+
+// Session is a db, a connection or a transaction
+type Session interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// ShowWarnings returns warnings of the last statement of session
+func ShowWarnings(ctx context.Context, s Session) ([]*Warning, error) {
+	rows, err := s.QueryContext(ctx, "SHOW WARNINGS")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	warnings := make([]*Warning, 0)
+	for rows.Next() {
+		w := &Warning{}
+		if err := rows.Scan(&w.Level, &w.Code, &w.Message); err != nil {
+			return nil, err
+		}
+		warnings = append(warnings, w)
+	}
+	return warnings, rows.Err()
+}
+
+// WarningDiffs returns the differences of level, code and count of two warning
+// lists, like "count: 2 vs 1" and "Warning 1292: 1 vs 0", warnings with the
+// ignored codes are skipped
+func WarningDiffs(warnings1 []*Warning, warnings2 []*Warning, ignore []uint16) []string {
+	ignored := make(map[uint16]bool)
+	for _, code := range ignore {
+		ignored[code] = true
+	}
+	count := func(warnings []*Warning) (int, map[string]int) {
+		total, counts := 0, make(map[string]int)
+		for _, w := range warnings {
+			if !ignored[w.Code] {
+				total++
+				counts[w.Level+" "+strconv.Itoa(int(w.Code))]++
+			}
+		}
+		return total, counts
+	}
+	total1, counts1 := count(warnings1)
+	total2, counts2 := count(warnings2)
+
+	diffs := make([]string, 0)
+	if total1 != total2 {
+		diffs = append(diffs, fmt.Sprintf("count: %d vs %d", total1, total2))
+	}
+	keys := make([]string, 0)
+	for key, c := range counts1 {
+		if counts2[key] != c {
+			keys = append(keys, key)
+		}
+	}
+	for key := range counts2 {
+		if _, ok := counts1[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		diffs = append(diffs, fmt.Sprintf("%s: %d vs %d", key, counts1[key], counts2[key]))
+	}
+	return diffs
+}
+
+// compare warnings of two results by options, consistent is whether
+// the statement is consistent after comparing warnings
+func (o *Options) compareWarnings(w1 *StmtWarnings, w2 *StmtWarnings, consistent bool) bool {
+	if !o.Warnings {
+		return consistent
+	}
+	w1.WarningDiffs = WarningDiffs(w1.Warnings, w2.Warnings, o.IgnoreWarnings)
+	w2.WarningDiffs = w1.WarningDiffs
+	if consistent && len(w1.WarningDiffs) > 0 {
+		w1.onlyWarnings, w2.onlyWarnings = true, true
+		return false
+	}
+	return consistent
+}
@@ -0,0 +1,62 @@
+package compare
+
+import (
+	"database/sql/driver"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestWarningDiffs(t *testing.T) {
+	warnings1 := []*Warning{
+		{"Warning", 1292, "Truncated incorrect DOUBLE value: 'a'"},
+		{"Warning", 1292, "Truncated incorrect DOUBLE value: 'b'"},
+		{"Note", 1003, "note"},
+	}
+	warnings2 := []*Warning{
+		{"Warning", 1292, "Truncated incorrect FLOAT value: 'a'"},
+		{"Warning", 1366, "Incorrect string value"},
+	}
+
+	assert.Equal(t, []string{}, WarningDiffs(warnings1, warnings1, nil))
+	assert.Equal(t, []string{
+		"count: 3 vs 2",
+		"Note 1003: 1 vs 0",
+		"Warning 1292: 2 vs 1",
+		"Warning 1366: 0 vs 1",
+	}, WarningDiffs(warnings1, warnings2, nil))
+	assert.Equal(t, []string{
+		"Warning 1292: 2 vs 1",
+		"Warning 1366: 0 vs 1",
+	}, WarningDiffs(warnings1, warnings2, []uint16{1003}))
+	assert.Equal(t, []string{}, WarningDiffs(warnings1, warnings2, []uint16{1003, 1292, 1366}))
+}
+
+func TestByWarnings(t *testing.T) {
+	sql := "SELECT a FROM t"
+	db1, mock1, err := sqlmock.New()
+	assert.Equal(t, nil, err)
+	db2, mock2, err := sqlmock.New()
+	assert.Equal(t, nil, err)
+	header := []string{"Level", "Code", "Message"}
+	for i := 0; i < 2; i++ {
+		mock1.ExpectQuery(sql).WillReturnRows(getRows([]string{"a"}, [][]driver.Value{{1}}))
+		mock1.ExpectQuery("SHOW WARNINGS").WillReturnRows(getRows(header, [][]driver.Value{
+			{"Warning", 1292, "Truncated incorrect DOUBLE value: 'a'"},
+		}))
+		mock2.ExpectQuery(sql).WillReturnRows(getRows([]string{"a"}, [][]driver.Value{{1}}))
+		mock2.ExpectQuery("SHOW WARNINGS").WillReturnRows(getRows(header, [][]driver.Value{}))
+	}
+
+	consistent, res1, res2 := ByQueryWithOptions(sql, db1, db2, &Options{Warnings: true})
+	assert.False(t, consistent)
+	assert.Equal(t, KindWarning, Kind(res1, res2))
+	warnings := WarningsOf(res1)
+	assert.Equal(t, []*Warning{{"Warning", 1292, "Truncated incorrect DOUBLE value: 'a'"}}, warnings.Warnings)
+	assert.Equal(t, []string{"count: 1 vs 0", "Warning 1292: 1 vs 0"}, warnings.WarningDiffs)
+
+	consistent, _, _ = ByQueryWithOptions(sql, db1, db2, &Options{Warnings: true, IgnoreWarnings: []uint16{1292}})
+	assert.True(t, consistent)
+	assert.Equal(t, nil, mock1.ExpectationsWereMet())
+	assert.Equal(t, nil, mock2.ExpectationsWereMet())
+}
@@ -0,0 +1,63 @@
+package gendata
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"strings"
+)
+
+// collations used by `_collation` if there is no collation in zz
+var defaultCollations = []string{
+	"utf8mb4_bin", "utf8mb4_general_ci", "utf8mb4_unicode_ci",
+	"utf8_bin", "utf8_general_ci", "latin1_bin", "latin1_swedish_ci",
+	"ascii_bin", "binary",
+}
+
+// the character set of collation, `utf8mb4_general_ci` -> `utf8mb4`
+func collationCharset(collation string) (string, error) {
+	collation = strings.ToLower(collation)
+	if collation == "binary" {
+		return collation, nil
+	}
+	index := strings.Index(collation, "_")
+	if index <= 0 {
+		return "", fmt.Errorf("illegal collation %s", collation)
+	}
+	return collation[:index], nil
+}
+
+var errIncompatibleCollation = errors.New("collation is not valid for character set")
+
+// check whether collation can be used with charset, undef means default
+func checkCollation(charset string, collation string) error {
+	if collation == "undef" {
+		return nil
+	}
+	collCharset, err := collationCharset(collation)
+	if err != nil {
+		return err
+	}
+	if charset != "undef" && !strings.EqualFold(charset, collCharset) {
+		return errIncompatibleCollation
+	}
+	return nil
+}
+
+// max length of table and column names in mysql
+const maxNameLength = 64
+
+// uniqueNames makes generated names unique and not too long
+type uniqueNames map[string]bool
+
+func (u uniqueNames) get(name string) string {
+	if len(name) > maxNameLength {
+		name = fmt.Sprintf("%s_%08x", name[:maxNameLength-9], crc32.ChecksumIEEE([]byte(name)))
+	}
+	unique := name
+	for i := 1; u[unique]; i++ {
+		unique = fmt.Sprintf("%s_%d", name, i)
+	}
+	u[unique] = true
+	return unique
+}
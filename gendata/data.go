@@ -1,11 +1,13 @@
 package gendata
 
 import (
+	"fmt"
 	"github.com/yuin/gopher-lua"
 	"github.com/pingcap/go-randgen/gendata/generators"
 	"log"
 	"math/rand"
 	"runtime/debug"
+	"sort"
 	"strings"
 )
 
@@ -13,6 +15,7 @@ const numberType = "numbers"
 const blobsType = "blobs"
 const temporalType = "temporals"
 const enumType = "enum"
+const jsonType = "jsons"
 
 // default summary type
 const stringsType = "strings"
@@ -20,29 +23,33 @@ const stringsType = "strings"
 // https://github.com/DQinYuan/randgenx/blob/master/lib/GenTest/App/Gendata.pm#L218
 // https://github.com/DQinYuan/randgenx/blob/master/lib/GenTest/App/Gendata.pm#L505
 var summaryType = map[string]string{
-	"int":     numberType,
-	"bigint":  numberType,
-	"float":   numberType,
-	"double":  numberType,
-	"decimal": numberType,
-	"numeric": numberType,
-	"fixed":   numberType,
-	"bool":    numberType,
-	"bit":     numberType,
-	"blob":    blobsType,
-	"text":    blobsType,
-	"binary":  blobsType,
-	"date":    temporalType,
-	"time":    temporalType,
-	"year":    temporalType,
-	"enum":    enumType,
-	"set":     enumType,
+	"int":       numberType,
+	"bigint":    numberType,
+	"float":     numberType,
+	"double":    numberType,
+	"decimal":   numberType,
+	"numeric":   numberType,
+	"fixed":     numberType,
+	"bool":      numberType,
+	"bit":       numberType,
+	"blob":      blobsType,
+	"text":      blobsType,
+	"binary":    blobsType,
+	"varbinary": blobsType,
+	"date":      temporalType,
+	"time":      temporalType,
+	"year":      temporalType,
+	"datetime":  temporalType,
+	"timestamp": temporalType,
+	"enum":      enumType,
+	"set":       enumType,
+	"json":      jsonType,
 }
 
 var defaultData = []*varWithDefault{
 	{
 		name:numberType,
-		defaul:[]string{"digit", "digit", "digit", "digit", "null"},
+		defaul:[]string{"digit", "digit", "digit", "digit", "precise", "null"},
 	},
 	{
 		name:stringsType,
@@ -58,41 +65,346 @@ var defaultData = []*varWithDefault{
 	},
 	{
 		name:enumType,
-		defaul:[]string{"letter", "letter", "letter", "letter", "null"},
+		defaul:[]string{"member", "member", "member", "member", "null"},
+	},
+	{
+		name:jsonType,
+		defaul:[]string{"json", "json", "json_object", "json_array", "null"},
+	},
+	{
+		name:"bit",
+		defaul:[]string{"bit", "bit", "bit", "bit", "null"},
+	},
+	{
+		name:"binary",
+		defaul:[]string{"binary", "binary", "binary", "binary", "null"},
+	},
+	{
+		name:"varbinary",
+		defaul:[]string{"varbinary", "varbinary", "varbinary", "varbinary", "null"},
+	},
+	{
+		name:"year",
+		defaul:[]string{"year", "year", "year", "1901", "2155", "null"},
+	},
+	{
+		name:"datetime",
+		defaul:[]string{"datetime", "datetime", "datetime", "datetime", "null"},
+	},
+	{
+		name:"timestamp",
+		defaul:[]string{"timestamp", "timestamp", "timestamp", "timestamp", "null"},
 	},
 }
 
+// generators depending on the definition of field, the declared
+// members of enum and set, or the length of bit, binary and varbinary
+var fieldGens = map[string]func(f *fieldExec) generators.Generator{
+	"member": func(f *fieldExec) generators.Generator {
+		return generators.NewMember(f.enumMembers(), f.dType() == "set")
+	},
+	"member_index": func(f *fieldExec) generators.Generator {
+		return generators.NewMemberIndex(len(f.enumMembers()), f.dType() == "set")
+	},
+	"bit": func(f *fieldExec) generators.Generator {
+		return generators.NewBit(f.length(1))
+	},
+	"binary": func(f *fieldExec) generators.Generator {
+		return generators.NewBinary(f.length(1), true)
+	},
+	"varbinary": func(f *fieldExec) generators.Generator {
+		return generators.NewBinary(f.length(16), false)
+	},
+	"edge": func(f *fieldExec) generators.Generator {
+		return generators.NewEdge(f.dType(), f.unsign, f.typeArgs(), f.enumMembers(), f.sqlMode)
+	},
+	"precise": func(f *fieldExec) generators.Generator {
+		if g := decimalGen(f); g != nil {
+			return g
+		}
+		if _, ok := f.fsp(); ok {
+			return fspGen(generators.Get(f.dType()), f)
+		}
+		if f.dType() == "date" || f.dType() == "year" {
+			return generators.Get(f.dType())
+		}
+		return generators.Get("digit")
+	},
+	"decimal": func(f *fieldExec) generators.Generator {
+		if g := decimalGen(f); g != nil {
+			return g
+		}
+		return generators.Get("decimal")
+	},
+}
+
+// descriptions of the generators which are only used in zz data,
+// other field bound generators are also registered in generators
+var fieldGenDescs = map[string]string{
+	"member":       "random declared member of enum, or combination of members of set",
+	"member_index": "random index of enum or bit mask of set, including out of range ones",
+	"edge":         "random boundary value of the field type",
+	"precise":      "random value filling the declared precision, scale or fsp of the field",
+}
+
+// FieldGenerators returns the generators depending on the definition
+// of field, they can only be used in zz data
+func FieldGenerators() []*generators.Info {
+	infos := make([]*generators.Info, 0, len(fieldGenDescs))
+	for name, desc := range fieldGenDescs {
+		infos = append(infos, &generators.Info{Name: name, Meta: generators.Meta{Desc: desc}, Plain: true})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+	return infos
+}
+
+// string types whose values are limited by the declared length
+var sizedTypes = map[string]bool{
+	"char":      true,
+	"varchar":   true,
+	"binary":    true,
+	"varbinary": true,
+}
+
+// probability of generating more fractional digits than the declared
+// scale or fsp, they are rounded by db, so the data can always be inserted
+const overflowRatio = 0.1
+
+// decimal filling the declared precision and scale of field,
+// nil if field is neither decimal nor float(M,D)
+func decimalGen(f *fieldExec) generators.Generator {
+	precision, scale, ok := f.precision()
+	if !ok {
+		return nil
+	}
+	return generators.NewOverflowDecimal(precision, scale, overflowRatio, f.unsign)
+}
+
+// fractional seconds of g respect the declared fsp of field
+func fspGen(g generators.Generator, f *fieldExec) generators.Generator {
+	fsp, ok := f.fsp()
+	if !ok {
+		return g
+	}
+	return generators.NewFsp(g, fsp, overflowRatio)
+}
+
+func init() {
+	for _, name := range []string{"datetime", "timestamp", "time"} {
+		name := name
+		fieldGens[name] = func(f *fieldExec) generators.Generator {
+			return fspGen(generators.Get(name), f)
+		}
+	}
+
+	// string generators respect the declared length of field
+	for _, name := range generators.TextGenNames() {
+		name := name
+		fieldGens[name] = func(f *fieldExec) generators.Generator {
+			return generators.NewTextGen(name, f.length(10))
+		}
+	}
+
+	// field bound generators shadow the registered ones of the same name
+	for name := range fieldGens {
+		generators.Reserve(name)
+	}
+}
+
+// field of generators used without a field, like keyfuns
+var unboundField = &fieldExec{}
+
+type fieldBoundGen struct {
+	newGen func(f *fieldExec) generators.Generator
+}
+
+func (g *fieldBoundGen) Gen() string {
+	return g.newGen(unboundField).Gen()
+}
+
+// bind field bound generators in a composed generator to f,
+// stateful generators are reset after bound
+func bindField(generator generators.Generator, f *fieldExec) generators.Generator {
+	compose, ok := generator.(*composeGen)
+	if !ok {
+		return generator
+	}
+
+	gs := make([]generators.Generator, len(compose.gs))
+	bound := false
+	for i, g := range compose.gs {
+		gs[i] = g
+		if fg, ok := g.(*fieldBoundGen); ok {
+			gs[i] = fg.newGen(f)
+			if s, ok := gs[i].(generators.Stateful); ok {
+				s.Reset()
+			}
+			bound = true
+		}
+	}
+	if !bound {
+		return generator
+	}
+	return &composeGen{gs}
+}
+
 type Data struct {
 	gens map[string]generators.Generator
+	// context of lua generators
+	ctx *rowContext
+	// ratio of boundary values mixed into every field, see edgeMix
+	edgeRatio float64
+	// options of json generators declared in zz
+	json *generators.JsonOptions
+	// what the sql_mode declared in zz accepts, see parseSqlMode
+	sqlMode generators.SqlMode
 }
 
 func newData(l *lua.LState) (*Data, error) {
-	datas, err := extractAllSlice(l, "data")
+	datas, err := extractAllValues(l, "data")
 	if err != nil {
 		return nil, err
 	}
 
+	json, err := jsonOptions(l)
+	if err != nil {
+		return nil, err
+	}
+	// for generators evaluated by lua helpers
+	l.SetGlobal(luaJsonOptions, &lua.LUserData{Value: json})
+
+	ctx := &rowContext{}
 	gens := make(map[string]generators.Generator)
-	for name, genNames := range datas {
-		gens[name] = composeFromGenName(genNames)
+	for name, vals := range datas {
+		gens[name] = composeFromLua(l, vals, ctx, json)
 	}
 
 	for _, dval := range defaultData {
 		_, ok := gens[dval.name]
+		// defaults of a type yield to its summary type declared in zz
+		if summaryName, isType := summaryType[dval.name]; !ok && isType && summaryName != dval.name {
+			_, ok = datas[summaryName]
+		}
+		if !ok {
+			gens[dval.name] = composeFromGenName(dval.defaul, json)
+		}
+	}
+
+	edgeRatio, err := extractRatio(l, "edge_ratio")
+	if err != nil {
+		return nil, err
+	}
+
+	sqlMode, err := extractSqlMode(l)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Data{gens, ctx, edgeRatio, json, parseSqlMode(sqlMode)}, nil
+}
+
+// optional number in [0, 1] in zz, 0 if it is not set
+func extractRatio(l *lua.LState, key string) (float64, error) {
+	val := l.Env.RawGetString(key)
+	if val == lua.LNil {
+		return 0, nil
+	}
+	ratio, ok := val.(lua.LNumber)
+	if !ok || ratio < 0 || ratio > 1 {
+		return 0, fmt.Errorf("%s must be a number between 0 and 1", key)
+	}
+	return float64(ratio), nil
+}
+
+// optional sql_mode of the servers which data is loaded into, like
+// `sql_mode = ''`, nil if it is not declared
+func extractSqlMode(l *lua.LState) (*string, error) {
+	val := l.Env.RawGetString("sql_mode")
+	if val == lua.LNil {
+		return nil, nil
+	}
+	mode, ok := val.(lua.LString)
+	if !ok {
+		return nil, fmt.Errorf("sql_mode must be a string")
+	}
+	sqlMode := string(mode)
+	return &sqlMode, nil
+}
+
+// zero dates are rejected by strict modes with NO_ZERO_DATE, and invalid
+// values like '' of enum are rejected by strict modes, they are not
+// generated unless a sql_mode accepting them is declared
+func parseSqlMode(sqlMode *string) generators.SqlMode {
+	if sqlMode == nil {
+		return generators.SqlMode{}
+	}
+	strict, noZeroDate := false, false
+	for _, mode := range strings.Split(strings.ToUpper(*sqlMode), ",") {
+		switch strings.TrimSpace(mode) {
+		case "STRICT_TRANS_TABLES", "STRICT_ALL_TABLES":
+			strict = true
+		case "NO_ZERO_DATE":
+			noZeroDate = true
+		case "TRADITIONAL":
+			strict, noZeroDate = true, true
+		}
+	}
+	return generators.SqlMode{ZeroDates: !strict || !noZeroDate, NonStrict: !strict}
+}
+
+// optional json options in zz, like `json = {depth = 2, keys = {'a', 'b'}}`
+func jsonOptions(l *lua.LState) (*generators.JsonOptions, error) {
+	val := l.Env.RawGetString("json")
+	if val == lua.LNil {
+		return generators.NewJsonOptions(0, nil), nil
+	}
+
+	table, ok := val.(*lua.LTable)
+	if !ok {
+		return nil, fmt.Errorf("json must be a lua Table")
+	}
+
+	depth := 0
+	if d := table.RawGetString("depth"); d != lua.LNil {
+		n, ok := d.(lua.LNumber)
 		if !ok {
-			gens[dval.name] = composeFromGenName(dval.defaul)
+			return nil, fmt.Errorf("json.depth must be a number")
 		}
+		depth = int(n)
+	}
+
+	keys, err := extractSlice(l, "json", "keys", nil)
+	if err != nil {
+		return nil, err
 	}
 
-	return &Data{gens}, nil
+	return generators.NewJsonOptions(depth, keys), nil
 }
 
-func composeFromGenName(genNames []string ) generators.Generator {
+// json generators are bound to json, nil means default
+func composeFromGenName(genNames []string, json *generators.JsonOptions) generators.Generator {
 	gs := make([]generators.Generator, 0)
 	for _, gName := range genNames {
-		gor := generators.Get(gName)
-		if gor != nil {
-			gs = append(gs, gor)
+		if newGen, ok := fieldGens[gName]; ok {
+			gs = append(gs, &fieldBoundGen{newGen})
+			continue
+		}
+		// registered or parameterized generator, like `int(1,10)`
+		gor, err := generators.Parse(gName)
+		if _, ok := gor.(generators.Stateful); ok && err == nil {
+			// every field has its own one
+			gName := gName
+			gs = append(gs, &fieldBoundGen{func(f *fieldExec) generators.Generator {
+				g, _ := generators.Parse(gName)
+				if u, ok := g.(*generators.Unique); ok && sizedTypes[f.dType()] {
+					u.SetMaxLength(f.length(1))
+				}
+				return g
+			}})
+		} else if err == nil {
+			gs = append(gs, json.Bind(gor))
 		} else { // constant
 			gs = append(gs, &constGen{gName})
 		}
@@ -101,58 +413,145 @@ func composeFromGenName(genNames []string ) generators.Generator {
 	return &composeGen{gs}
 }
 
-func (d *Data) getRecordGen(fields []*fieldExec) recordGen {
-	gens := make([]generators.Generator, 0)
+// tableCharset is the character set of table, string fields can choose
+// generators by their character set (or the one of table) in data
+func (d *Data) getRecordGen(fields []*fieldExec, tableCharset string) recordGen {
+	gens := make([]generators.Generator, 0, len(fields))
 	for _, f := range fields {
-		// full type name
-		name := f.tp
+		f = d.withSqlMode(f)
+		gens = append(gens, d.mixEdge(d.fieldGen(f, tableCharset), f))
+	}
+
+	return recordGen{gens, d.ctx}
+}
+
+func (d *Data) fieldGen(f *fieldExec, tableCharset string) generators.Generator {
+	// full type name
+	name := f.tp
+	generator, ok := d.gens[name]
+	if ok {
+		return bindField(generator, f)
+	}
+
+	// simple type name
+	index := strings.Index(name, "(")
+	if index != -1 {
+		name = name[:index]
 		generator, ok := d.gens[name]
 		if ok {
-			gens = append(gens, generator)
-			continue
-		}
-
-		// simple type name
-		index := strings.Index(name, "(")
-		if index != -1 {
-			name = name[:index]
-			generator, ok := d.gens[name]
-			if ok {
-				gens = append(gens, generator)
-				continue
-			}
+			return bindField(generator, f)
 		}
+	}
 
-		// finally summary name
-		summaryName, ok := summaryType[name]
-		if !ok {
-			summaryName = stringsType
+	// character set
+	if canCollate[f.dType()] {
+		charset := f.charset
+		if charset == "" {
+			charset = tableCharset
 		}
-		generator, ok = d.gens[summaryName]
-		if !ok {
-			log.Fatalf("shouldn't run here, summary name %s \n %s", summaryName, debug.Stack())
+		generator, ok := d.gens[charset]
+		if charset != "" && ok {
+			return bindField(generator, f)
 		}
+	}
+
+	// finally summary name
+	summaryName, ok := summaryType[name]
+	if !ok {
+		summaryName = stringsType
+	}
+	generator, ok = d.gens[summaryName]
+	if !ok {
+		log.Fatalf("shouldn't run here, summary name %s \n %s", summaryName, debug.Stack())
+	}
+	generator = bindField(generator, f)
+
+	// decimal generators of the declared precision are already unsigned
+	if f.unsign && decimalGen(f) == nil {
+		return &unsignGen{generator, 10, "1"}
+	}
+	return generator
+}
 
-		if f.unsign {
-			gens = append(gens, &unsignGen{generator, 10, "1"})
-		} else {
-			gens = append(gens, generator)
+// copy of f knowing what the sql_mode accepts, for edge values
+func (d *Data) withSqlMode(f *fieldExec) *fieldExec {
+	if d.sqlMode == (generators.SqlMode{}) {
+		return f
+	}
+	copied := *f
+	copied.sqlMode = d.sqlMode
+	return &copied
+}
+
+// mix edge values of f into generator at edge ratio, except primary keys and
+// stateful generators like seq and unique, whose values must stay unique
+func (d *Data) mixEdge(generator generators.Generator, f *fieldExec) generators.Generator {
+	if d.edgeRatio <= 0 || f.pk || hasStateful(generator) {
+		return generator
+	}
+	return &edgeMix{generator, fieldGens["edge"](f), d.edgeRatio}
+}
+
+func hasStateful(generator generators.Generator) bool {
+	switch g := generator.(type) {
+	case generators.Stateful:
+		return true
+	case *composeGen:
+		for _, sub := range g.gs {
+			if hasStateful(sub) {
+				return true
+			}
 		}
+	case *unsignGen:
+		return hasStateful(g.gen)
+	}
+	return false
+}
+
+// edgeMix generates boundary values of field at ratio, normal values otherwise
+type edgeMix struct {
+	gen   generators.Generator
+	edge  generators.Generator
+	ratio float64
+}
+
+func (e *edgeMix) Gen() string {
+	if rand.Float64() < e.ratio {
+		return e.edge.Gen()
 	}
+	return e.gen.Gen()
+}
 
-	return recordGen(gens)
+type recordGen struct {
+	gens []generators.Generator
+	ctx  *rowContext
 }
 
-type recordGen []generators.Generator
+// set the table and row number of next row for lua generators
+func (r recordGen) setRow(table string, row int) {
+	if r.ctx != nil {
+		r.ctx.table = table
+		r.ctx.row = row
+		r.ctx.err = nil
+	}
+}
+
+// the first error of lua generators in current row
+func (r recordGen) err() error {
+	if r.ctx != nil {
+		return r.ctx.err
+	}
+	return nil
+}
 
 func (r recordGen) oneRow(row []string)  {
-	if len(r) != len(row) {
+	if len(r.gens) != len(row) {
 		log.Fatalf("record gen illegal, expect len: %d, real row container len %d\n",
-			len(r), len(row))
+			len(r.gens), len(row))
 	}
 
-	for i := range r {
-		row[i] = r[i].Gen()
+	for i := range r.gens {
+		row[i] = r.gens[i].Gen()
 	}
 }
 
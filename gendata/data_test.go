@@ -1,8 +1,12 @@
 package gendata
 
 import (
+	"github.com/pingcap/go-randgen/gendata/generators"
 	"github.com/stretchr/testify/assert"
+	"strconv"
+	"strings"
 	"testing"
+	"unicode/utf8"
 )
 
 func TestData(t *testing.T) {
@@ -35,15 +39,395 @@ data = {
 				name:"",
 				tp: "enum",
 			},
-		})
+		}, "")
 
 		row := make([]string, 3)
 
 		recordGen.oneRow(row)
 	})
 
+	t.Run("test json record gen", func(t *testing.T) {
+		l, err := runLua(`
+data = {
+    jsons = {'json_object'},
+}
+json = {depth = 1, keys = {'k'}}
+`)
+		assert.Equal(t, nil, err)
+		data, err := newData(l)
+		assert.Equal(t, nil, err)
+
+		// another config does not change the json options of data
+		other, err := runLua(`data = {} json = {depth = 3, keys = {'other'}}`)
+		assert.Equal(t, nil, err)
+		_, err = newData(other)
+		assert.Equal(t, nil, err)
+
+		recordGen := data.getRecordGen([]*fieldExec{{tp: "json"}}, "")
+		row := make([]string, 1)
+		for i := 0; i < 10; i++ {
+			recordGen.oneRow(row)
+			assert.True(t, row[0] == "'{}'" || strings.HasPrefix(row[0], `'{"k": `), row[0])
+		}
+
+		keyf := NewKeyfun(nil, nil)
+		keyf.bindJson(data.json)
+		for i := 0; i < 10; i++ {
+			path, err := keyf["_json_path"]()
+			assert.Equal(t, nil, err)
+			assert.True(t, path == "'$'" || path == "'$.k'" || path == "'$[0]'" ||
+				path == "'$[1]'" || path == "'$[2]'" || path == "'$[3]'", path)
+		}
+	})
+
+	t.Run("test field bound record gen", func(t *testing.T) {
+		// numbers and blobs are not declared, so bit and varbinary use their own defaults
+		l, err := runLua(`data = {strings = {'null', 'english'}}`)
+		assert.Equal(t, nil, err)
+		data, err := newData(l)
+		assert.Equal(t, nil, err)
+
+		members := []string{"a", "A", ""}
+		recordGen := data.getRecordGen([]*fieldExec{
+			{tp: "set('a','A','')", members: members},
+			{tp: "varbinary(2)"},
+			{tp: "bit(2)"},
+		}, "")
+		row := make([]string, 3)
+		for i := 0; i < 50; i++ {
+			recordGen.oneRow(row)
+			if row[0] != "NULL" && row[0] != "null" {
+				for _, m := range strings.Split(strings.Trim(row[0], "'"), ",") {
+					assert.Contains(t, members, m)
+				}
+			}
+			assert.True(t, len(row[1]) <= len("x'ffff'"), row[1])
+			assert.True(t, len(row[2]) <= len("b'11'"), row[2])
+		}
+	})
+}
+
+func TestLuaData(t *testing.T) {
+	l, err := runLua(`
+data = {
+    int = {function(column, row, table) return row end},
+    varchar = {fmt("'%s-%s-%s'", function(column, row, table) return column end,
+        oneof('x', range(1, 3)), 'letter')},
+    char = {oneof(range(10, 20, 5), function() return nil end)},
+    text = {fmt("'%s'", function() return "'it\\'s'" end)},
+}
+`)
+	assert.Equal(t, nil, err)
 
+	data, err := newData(l)
+	assert.Equal(t, nil, err)
 
+	recordGen := data.getRecordGen([]*fieldExec{
+		{name: "col_int", tp: "int"},
+		{name: "col_varchar", tp: "varchar(10)"},
+		{name: "col_char", tp: "char(2)"},
+		{name: "col_text", tp: "text"},
+	}, "")
+	table := &tableStmt{name: "t", rowNum: 20}
+	rows := 0
+	err = genRows(recordGen, table, func(pk int, row []string) {
+		assert.Equal(t, strconv.Itoa(pk), row[0])
+		assert.Regexp(t, `^'col_varchar-(x|1|2|3)-[a-z]'$`, row[1])
+		assert.Contains(t, []string{"10", "15", "20", "NULL"}, row[2])
+		// values spliced by fmt are escaped again
+		assert.Equal(t, `'it\'s'`, row[3])
+		rows++
+	})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 20, rows)
 
+	// errors of lua generators are returned instead of exiting
+	l, err = runLua(`data = {int = {function(column, row) if row == 3 then error("boom") end return row end}}`)
+	assert.Equal(t, nil, err)
+	data, err = newData(l)
+	assert.Equal(t, nil, err)
+	rows = 0
+	err = genRows(data.getRecordGen([]*fieldExec{{name: "col_int", tp: "int"}}, ""), table,
+		func(pk int, row []string) { rows++ })
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "boom")
+	assert.Equal(t, 3, rows)
+}
+
+func TestCharsetData(t *testing.T) {
+	l, err := runLua(`
+data = {
+    latin1 = {'latin1'},
+    utf8mb4 = {'exact_length'},
+}
+`)
+	assert.Equal(t, nil, err)
+
+	data, err := newData(l)
+	assert.Equal(t, nil, err)
+
+	fields := []*fieldExec{
+		{name: "a", tp: "varchar(3)", charset: "utf8mb4"},
+		{name: "b", tp: "char(4)"},
+		{name: "c", tp: "int"},
+	}
+	row := make([]string, 3)
+
+	recordGen := data.getRecordGen(fields, "latin1")
+	for i := 0; i < 20; i++ {
+		recordGen.oneRow(row)
+		// exactly 3 characters after unquoting
+		val, _ := unquoteLiteral(row[0])
+		assert.Equal(t, 3, utf8.RuneCountInString(val), row[0])
+		for _, r := range row[1] {
+			assert.True(t, r <= 0xff, row[1])
+		}
+	}
+}
+
+func TestEdgeRatio(t *testing.T) {
+	l, err := runLua(`
+data = {
+    numbers = {'1'},
+}
+edge_ratio = 0.5
+`)
+	assert.Equal(t, nil, err)
+
+	data, err := newData(l)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0.5, data.edgeRatio)
+
+	recordGen := data.getRecordGen([]*fieldExec{{tp: "int"}}, "")
+	row := make([]string, 1)
+	edges := 0
+	for i := 0; i < 200; i++ {
+		recordGen.oneRow(row)
+		if row[0] != "1" {
+			edges++
+			assert.Contains(t, []string{"-2147483648", "2147483647", "0", "-0", "-1"}, row[0])
+		}
+	}
+	assert.True(t, edges > 0 && edges < 200)
+
+	l, err = runLua(`edge_ratio = 2
+data = {}`)
+	assert.Equal(t, nil, err)
+	_, err = newData(l)
+	assert.Equal(t, "edge_ratio must be a number between 0 and 1", err.Error())
+	// stateful generators and primary keys keep unique values
+	l, err = runLua(`
+data = {
+    numbers = {'seq'},
+    varchar = {"'x'"},
+}
+edge_ratio = 1
+`)
+	assert.Equal(t, nil, err)
+	data, err = newData(l)
+	assert.Equal(t, nil, err)
+	recordGen = data.getRecordGen([]*fieldExec{{tp: "int"}, {tp: "varchar(5)", pk: true}}, "")
+	row = make([]string, 2)
+	for i := 1; i <= 20; i++ {
+		recordGen.oneRow(row)
+		assert.Equal(t, strconv.Itoa(i), row[0])
+		assert.Equal(t, "'x'", row[1])
+	}
+}
+
+func TestZeroDates(t *testing.T) {
+	mode := func(m string) *string {
+		return &m
+	}
+	assert.Equal(t, generators.SqlMode{}, parseSqlMode(nil))
+	assert.Equal(t, generators.SqlMode{ZeroDates: true, NonStrict: true}, parseSqlMode(mode("")))
+	assert.Equal(t, generators.SqlMode{ZeroDates: true}, parseSqlMode(mode("STRICT_TRANS_TABLES")))
+	assert.Equal(t, generators.SqlMode{ZeroDates: true, NonStrict: true},
+		parseSqlMode(mode("no_zero_date,no_engine_substitution")))
+	assert.Equal(t, generators.SqlMode{}, parseSqlMode(mode("STRICT_TRANS_TABLES,NO_ZERO_DATE")))
+	assert.Equal(t, generators.SqlMode{}, parseSqlMode(mode("TRADITIONAL")))
+
+	for _, c := range []struct {
+		zz   string
+		zero bool
+	}{
+		{"data = {}", false},
+		{"data = {}\nsql_mode = ''", true},
+	} {
+		l, err := runLua(c.zz + "\nedge_ratio = 1")
+		assert.Equal(t, nil, err)
+		data, err := newData(l)
+		assert.Equal(t, nil, err)
+
+		recordGen := data.getRecordGen([]*fieldExec{{tp: "date"}}, "")
+		row := make([]string, 1)
+		zeros := 0
+		for i := 0; i < 100; i++ {
+			recordGen.oneRow(row)
+			if row[0] == "'0000-00-00'" {
+				zeros++
+			}
+		}
+		assert.Equal(t, c.zero, zeros > 0, c.zz)
+	}
+}
+
+func TestStrictEdges(t *testing.T) {
+	for _, c := range []struct {
+		zz    string
+		empty bool
+	}{
+		{"data = {}", false},
+		{"data = {}\nsql_mode = 'NO_ENGINE_SUBSTITUTION'", true},
+	} {
+		l, err := runLua(c.zz + "\nedge_ratio = 1")
+		assert.Equal(t, nil, err)
+		data, err := newData(l)
+		assert.Equal(t, nil, err)
+
+		recordGen := data.getRecordGen([]*fieldExec{
+			{tp: "enum('a','b')", members: []string{"a", "b"}},
+			{tp: "float(5,2)"},
+		}, "")
+		row := make([]string, 2)
+		empties := 0
+		for i := 0; i < 100; i++ {
+			recordGen.oneRow(row)
+			if row[0] == "''" {
+				empties++
+			}
+			assert.Contains(t, []string{"999.99", "-999.99", "0", "-0", "0.01", "-0.01"}, row[1])
+		}
+		assert.Equal(t, c.empty, empties > 0, c.zz)
+	}
+}
+
+func TestParamData(t *testing.T) {
+	l, err := runLua(`
+data = {
+    int = {'int(1,3)'},
+    varchar = {"choice('x', 'y')"},
+}
+`)
+	assert.Equal(t, nil, err)
+
+	data, err := newData(l)
+	assert.Equal(t, nil, err)
+
+	recordGen := data.getRecordGen([]*fieldExec{{tp: "int"}, {tp: "varchar(10)"}}, "")
+	row := make([]string, 2)
+	for i := 0; i < 20; i++ {
+		recordGen.oneRow(row)
+		assert.Contains(t, []string{"1", "2", "3"}, row[0])
+		assert.Contains(t, []string{"'x'", "'y'"}, row[1])
+	}
+}
+
+func TestPrecisionData(t *testing.T) {
+	l, err := runLua(`
+data = {
+    numbers = {'precise'},
+    temporals = {'time'},
+    datetime = {'datetime'},
+}
+`)
+	assert.Equal(t, nil, err)
+
+	data, err := newData(l)
+	assert.Equal(t, nil, err)
+
+	recordGen := data.getRecordGen([]*fieldExec{
+		{tp: "decimal(40,20)"},
+		{tp: "decimal(5,2)", unsign: true},
+		{tp: "datetime(6)"},
+		{tp: "time"},
+		{tp: "int"},
+	}, "")
+	row := make([]string, 5)
+	for i := 0; i < 50; i++ {
+		recordGen.oneRow(row)
+		assert.Regexp(t, `^-?\d{1,20}\.\d{20,23}$`, row[0])
+		assert.Regexp(t, `^\d{1,3}\.\d{2,5}$`, row[1])
+		assert.Regexp(t, `^'(\d{4}-\d{2}-\d{2} )?\d{2}:\d{2}:\d{2}\.\d{6,9}'$`, row[2])
+		assert.Regexp(t, `^'(\d{4}-\d{2}-\d{2} )?\d{2}:\d{2}:\d{2}(\.\d{1,3})?'$`, row[3])
+		assert.Regexp(t, `^\d$`, row[4])
+	}
+}
+
+func TestDatetimeData(t *testing.T) {
+	l, err := runLua(`data = {}`)
+	assert.Equal(t, nil, err)
+	data, err := newData(l)
+	assert.Equal(t, nil, err)
+
+	// no time or year literals in datetime and timestamp fields
+	recordGen := data.getRecordGen([]*fieldExec{{tp: "datetime(3)"}, {tp: "timestamp"}}, "")
+	row := make([]string, 2)
+	for i := 0; i < 50; i++ {
+		recordGen.oneRow(row)
+		assert.Regexp(t, `^(null|'\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}(\.\d{3,6})?')$`, row[0])
+		assert.Regexp(t, `^(null|\d{14}(\.\d{1,3})?)$`, row[1])
+	}
+}
+
+func TestSummaryData(t *testing.T) {
+	l, err := runLua(`
+data = {
+    numbers = {'7'},
+    blobs = {'blob'},
+    temporals = {'2019-08-23'},
+    year = {'2000'},
+}
+`)
+	assert.Equal(t, nil, err)
+	data, err := newData(l)
+	assert.Equal(t, nil, err)
+
+	// the declared summary types win over the defaults of their types
+	recordGen := data.getRecordGen([]*fieldExec{{tp: "bit(3)"}, {tp: "binary(4)"}, {tp: "varbinary(4)"},
+		{tp: "datetime"}, {tp: "timestamp"}, {tp: "year"}, {tp: "char"}}, "")
+	row := make([]string, 7)
+	recordGen.oneRow(row)
+	assert.Equal(t, []string{"7", "blob", "blob", "2019-08-23", "2019-08-23", "2000"}, row[:6])
+	assert.Regexp(t, `^(null|'[a-zA-Z]')$`, row[6])
+}
+
+func TestStatefulData(t *testing.T) {
+	l, err := runLua(`
+data = {
+    int = {'seq'},
+    varchar = {'unique(letter)'},
+}
+`)
+	assert.Equal(t, nil, err)
+
+	data, err := newData(l)
+	assert.Equal(t, nil, err)
+
+	fields := []*fieldExec{{tp: "int"}, {tp: "int"}, {tp: "varchar(10)"}, {tp: "varchar(3)"}}
+	for table := 0; table < 2; table++ {
+		recordGen := data.getRecordGen(fields, "")
+		seen := make(map[string]bool)
+		seenShort := make(map[string]bool)
+		row := make([]string, 4)
+		for i := 1; i <= 30; i++ {
+			recordGen.oneRow(row)
+			// every table and column has its own sequence
+			assert.Equal(t, strconv.Itoa(i), row[0])
+			assert.Equal(t, strconv.Itoa(i), row[1])
+			assert.False(t, seen[row[2]], row[2])
+			seen[row[2]] = true
+			// suffixes are kept in the declared length
+			assert.False(t, seenShort[row[3]], row[3])
+			assert.True(t, len(row[3]) <= 5, row[3])
+			seenShort[row[3]] = true
+		}
+	}
+}
 
+func TestReservedFieldGens(t *testing.T) {
+	for _, name := range []string{"member", "edge", "precise"} {
+		err := generators.Register(name, generators.Get("digit"))
+		assert.Equal(t, "generator name "+name+" is reserved", err.Error())
+	}
 }
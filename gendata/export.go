@@ -0,0 +1,253 @@
+package gendata
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// FileFormat is the format of data files used by `LOAD DATA`
+type FileFormat struct {
+	Ext       string
+	Separator string
+	// empty means values are not enclosed
+	Enclosed string
+}
+
+var (
+	CsvFormat = &FileFormat{Ext: "csv", Separator: ",", Enclosed: `"`}
+	TsvFormat = &FileFormat{Ext: "tsv", Separator: "\t"}
+)
+
+// escape sequences of `ESCAPED BY '\\'`, `\N` means NULL
+var fileEscapes = map[byte]string{
+	'\\': `\\`,
+	'\n': `\n`,
+	'\r': `\r`,
+	'\t': `\t`,
+	0:    `\0`,
+	'"':  `\"`,
+}
+
+func (f *FileFormat) escape(val string, isNull bool) string {
+	if isNull {
+		return `\N`
+	}
+
+	buf := &strings.Builder{}
+	buf.WriteString(f.Enclosed)
+	for i := 0; i < len(val); i++ {
+		if escaped, ok := fileEscapes[val[i]]; ok {
+			buf.WriteString(escaped)
+		} else {
+			buf.WriteByte(val[i])
+		}
+	}
+	buf.WriteString(f.Enclosed)
+	return buf.String()
+}
+
+// TableFile is the ddl of a table and the data file of its rows
+type TableFile struct {
+	Name   string
+	Ddl    string
+	Path   string
+	Format *FileFormat
+	// columns in the order of values in the data file
+	Columns []string
+	// BIT columns, whose decimal values are loaded by user variables
+	BitColumns map[string]bool
+}
+
+// LoadSql returns the `LOAD DATA LOCAL INFILE` statement of the data file
+func (t *TableFile) LoadSql() string {
+	enclosed := ""
+	if t.Format.Enclosed != "" {
+		enclosed = fmt.Sprintf(" OPTIONALLY ENCLOSED BY '%s'", t.Format.Enclosed)
+	}
+	sep := t.Format.Separator
+	if sep == "\t" {
+		sep = `\t`
+	}
+	path := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(t.Path)
+	return fmt.Sprintf(`LOAD DATA LOCAL INFILE '%s' INTO TABLE %s FIELDS TERMINATED BY '%s'%s `+
+		`ESCAPED BY '\\' LINES TERMINATED BY '\n'%s`, path, t.Name, sep, enclosed, t.bitColumnsSql())
+}
+
+// a text like `5` is loaded into a BIT column as the bytes of the text,
+// so the values of BIT columns are loaded into user variables and casted
+func (t *TableFile) bitColumnsSql() string {
+	if len(t.BitColumns) == 0 {
+		return ""
+	}
+
+	cols := make([]string, 0, len(t.Columns))
+	sets := make([]string, 0, len(t.BitColumns))
+	for _, col := range t.Columns {
+		if t.BitColumns[col] {
+			cols = append(cols, "@"+col)
+			sets = append(sets, fmt.Sprintf("%s = CAST(@%s AS UNSIGNED)", col, col))
+		} else {
+			cols = append(cols, col)
+		}
+	}
+	return fmt.Sprintf(" (%s) SET %s", strings.Join(cols, ", "), strings.Join(sets, ", "))
+}
+
+// FilesByZz writes the rows of every table generated by zz into
+// dir/<table name>.<format ext>
+func FilesByZz(zz string, dir string, format *FileFormat) ([]*TableFile, Keyfun, error) {
+	config, err := configByZz(zz)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return FilesByConfig(config, dir, format)
+}
+
+func FilesByConfig(config *ZzConfig, dir string, format *FileFormat) ([]*TableFile, Keyfun, error) {
+	tableStmts, fieldExecs, err := config.genDdls()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, nil, err
+	}
+
+	files := make([]*TableFile, 0, len(tableStmts))
+	for _, tableStmt := range tableStmts {
+		recordGor := config.Data.getRecordGen(fieldExecs, tableStmt.charset)
+		file := &TableFile{
+			Name:   tableStmt.name,
+			Ddl:    tableStmt.ddl,
+			Path:   filepath.Join(dir, tableStmt.name+"."+format.Ext),
+			Format: format,
+
+			Columns:    []string{zzPkField.name},
+			BitColumns: make(map[string]bool),
+		}
+		for _, field := range fieldExecs {
+			file.Columns = append(file.Columns, field.name)
+			if field.dType() == "bit" {
+				file.BitColumns[field.name] = true
+			}
+		}
+		if err := writeDataFile(recordGor, tableStmt, file); err != nil {
+			return nil, nil, err
+		}
+		files = append(files, file)
+	}
+
+	keyf := NewKeyfun(tableStmts, fieldExecs)
+	keyf.bindJson(config.Data.json)
+	return files, keyf, nil
+}
+
+func writeDataFile(recordGor recordGen, table *tableStmt, file *TableFile) error {
+	f, err := os.Create(file.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	var writeErr error
+	write := func(s string) {
+		if writeErr == nil {
+			_, writeErr = w.WriteString(s)
+		}
+	}
+	err = genRows(recordGor, table, func(pk int, row []string) {
+		write(file.Format.escape(strconv.Itoa(pk), false))
+		for _, literal := range row {
+			write(file.Format.Separator)
+			write(file.Format.escape(unquoteLiteral(literal)))
+		}
+		write("\n")
+	})
+
+	if err != nil {
+		return err
+	}
+	if writeErr != nil {
+		return writeErr
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// unquoteLiteral returns the value of a sql literal generated by generators,
+// isNull is true if it is NULL
+func unquoteLiteral(literal string) (val string, isNull bool) {
+	if strings.EqualFold(literal, "null") {
+		return "", true
+	}
+
+	if len(literal) < 2 {
+		return literal, false
+	}
+
+	switch {
+	case literal[0] == '\'' || literal[0] == '"':
+		if literal[len(literal)-1] == literal[0] {
+			return unescapeString(literal[1:len(literal)-1], literal[0]), false
+		}
+	case len(literal) > 3 && (literal[0] == 'x' || literal[0] == 'X') &&
+		literal[1] == '\'' && literal[len(literal)-1] == '\'':
+		if bs, err := hex.DecodeString(literal[2 : len(literal)-1]); err == nil {
+			return string(bs), false
+		}
+	case len(literal) > 2 && literal[0] == '0' && literal[1] == 'x':
+		if bs, err := hex.DecodeString(literal[2:]); err == nil {
+			return string(bs), false
+		}
+	case len(literal) > 3 && (literal[0] == 'b' || literal[0] == 'B') &&
+		literal[1] == '\'' && literal[len(literal)-1] == '\'':
+		// BIT columns are loaded by the decimal value, see TableFile.LoadSql
+		if bits, ok := new(big.Int).SetString(literal[2:len(literal)-1], 2); ok {
+			return bits.String(), false
+		}
+	}
+
+	return literal, false
+}
+
+var stringUnescapes = map[byte]byte{
+	'0': 0,
+	'n': '\n',
+	'r': '\r',
+	't': '\t',
+	'b': '\b',
+	'Z': 26,
+}
+
+// unescape the content of a quoted sql string
+func unescapeString(s string, quote byte) string {
+	buf := &strings.Builder{}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '\\' && i+1 < len(s) {
+			i++
+			if unescaped, ok := stringUnescapes[s[i]]; ok {
+				buf.WriteByte(unescaped)
+			} else {
+				buf.WriteByte(s[i])
+			}
+			continue
+		}
+		// doubled quote
+		if c == quote && i+1 < len(s) && s[i+1] == quote {
+			i++
+		}
+		buf.WriteByte(c)
+	}
+	return buf.String()
+}
@@ -0,0 +1,110 @@
+package gendata
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUnquoteLiteral(t *testing.T) {
+	cases := []struct {
+		literal string
+		val     string
+		isNull  bool
+	}{
+		{"null", "", true},
+		{"NULL", "", true},
+		{"12.5", "12.5", false},
+		{"-3", "-3", false},
+		{`'abc'`, "abc", false},
+		{`"abc"`, "abc", false},
+		{`'it''s'`, "it's", false},
+		{`'a\'b\\c\nd\te\0'`, "a'b\\c\nd\te\x00", false},
+		{`x'6162'`, "ab", false},
+		{`0x6162`, "ab", false},
+		{`b'01100001'`, "97", false},
+		{`b'0'`, "0", false},
+		{`b'000'`, "0", false},
+	}
+
+	for _, c := range cases {
+		val, isNull := unquoteLiteral(c.literal)
+		assert.Equal(t, c.val, val, c.literal)
+		assert.Equal(t, c.isNull, isNull, c.literal)
+	}
+}
+
+func TestFileEscape(t *testing.T) {
+	assert.Equal(t, `\N`, CsvFormat.escape("", true))
+	assert.Equal(t, `\N`, TsvFormat.escape("", true))
+	assert.Equal(t, `"a,b"`, CsvFormat.escape("a,b", false))
+	assert.Equal(t, `"a\"b\\c\nd\te\0"`, CsvFormat.escape("a\"b\\c\nd\te\x00", false))
+	assert.Equal(t, `a\tb\nc`, TsvFormat.escape("a\tb\nc", false))
+}
+
+func TestFilesByConfig(t *testing.T) {
+	l, err := runLua(`
+tables = {
+    rows = {10, 0},
+}
+
+fields = {
+    types = {'int', 'varchar'},
+}
+
+data = {
+    numbers = {'null', 'int'},
+    strings = {'null', 'letter'},
+}
+`)
+	assert.Equal(t, nil, err)
+
+	config, err := newZzConfig(l)
+	assert.Equal(t, nil, err)
+
+	dir, err := ioutil.TempDir("", "randgen_export")
+	assert.Equal(t, nil, err)
+	defer os.RemoveAll(dir)
+
+	for _, format := range []*FileFormat{CsvFormat, TsvFormat} {
+		files, keyf, err := FilesByConfig(config, dir, format)
+		assert.Equal(t, nil, err)
+		assert.NotNil(t, keyf)
+		assert.Equal(t, 2, len(files))
+
+		for i, file := range files {
+			assert.Equal(t, filepath.Join(dir, file.Name+"."+format.Ext), file.Path)
+			assert.True(t, strings.HasPrefix(file.Ddl, "create table "+file.Name))
+
+			bs, err := ioutil.ReadFile(file.Path)
+			assert.Equal(t, nil, err)
+			lines := strings.Count(string(bs), "\n")
+			if i == 0 {
+				assert.Equal(t, 10, lines)
+			} else {
+				assert.Equal(t, 0, lines)
+			}
+
+			loadSql := file.LoadSql()
+			assert.True(t, strings.HasPrefix(loadSql,
+				"LOAD DATA LOCAL INFILE '"+file.Path+"' INTO TABLE "+file.Name+" FIELDS TERMINATED BY "))
+			assert.Equal(t, format == CsvFormat, strings.Contains(loadSql, `OPTIONALLY ENCLOSED BY '"'`))
+			assert.False(t, strings.Contains(loadSql, " SET "))
+		}
+	}
+}
+
+func TestLoadSqlOfBitColumns(t *testing.T) {
+	file := &TableFile{
+		Name:       "t",
+		Path:       "/tmp/t.csv",
+		Format:     CsvFormat,
+		Columns:    []string{"pk", "col_bit", "col_int"},
+		BitColumns: map[string]bool{"col_bit": true},
+	}
+	assert.True(t, strings.HasSuffix(file.LoadSql(),
+		`LINES TERMINATED BY '\n' (pk, @col_bit, col_int) SET col_bit = CAST(@col_bit AS UNSIGNED)`))
+}
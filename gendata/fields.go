@@ -2,11 +2,14 @@ package gendata
 
 import (
 	"fmt"
+	"hash/crc32"
+	"strconv"
+	"github.com/pingcap/go-randgen/gendata/generators"
 	"github.com/yuin/gopher-lua"
 	"strings"
 )
 
-var fieldsTmpl = mustParse("fields", "`{{.fname}}` {{.types}} {{.sign}} {{.keys}}")
+var fieldsTmpl = mustParse("fields", "`{{.fname}}` {{.types}} {{.charsets}} {{.collations}} {{.sign}} {{.keys}}")
 
 var fieldVars = []*varWithDefault{
 	{
@@ -17,6 +20,15 @@ var fieldVars = []*varWithDefault{
 		"keys",
 		[]string{"undef", "key"},
 	},
+	{
+		"charsets",
+		[]string{"undef"},
+	},
+	// charsets must be before it
+	{
+		"collations",
+		[]string{"undef"},
+	},
 	// to ensure ignore efficient, sign should always be the last
 	{
 		"sign",
@@ -37,6 +49,18 @@ var canUnSign = map[string]bool{
 	"decimal":   true,
 }
 
+// types which can have character set and collation
+var canCollate = map[string]bool{
+	"char":       true,
+	"varchar":    true,
+	"tinytext":   true,
+	"text":       true,
+	"mediumtext": true,
+	"longtext":   true,
+	"enum":       true,
+	"set":        true,
+}
+
 const enumVals = "('a','b','c','d','e','f','g','h','i','j','k','l'," +
 	"'m','n','o','p','q','r','s','t','u','v','w','x','y','z')"
 
@@ -55,7 +79,15 @@ var fieldFuncs = map[string]func(text string, fname string, ctx *fieldExec) (tar
 			ctx.canUnSign = true
 		}
 		if tp == "set" || tp == "enum" {
-			text = tp + enumVals
+			// members are not declared in zz
+			if index == -1 {
+				text = tp + enumVals
+			}
+			members, err := parseMembers(text[len(tp):])
+			if err != nil {
+				return "", false, nil, err
+			}
+			ctx.members = members
 		}
 		return text, false, nil, nil
 	},
@@ -63,9 +95,48 @@ var fieldFuncs = map[string]func(text string, fname string, ctx *fieldExec) (tar
 		if text == "undef" {
 			return "", false, nil, nil
 		}
+		// json column can not be indexed directly
+		if ctx.dType() == "json" {
+			return "", true, nil, nil
+		}
+		ctx.indexed = true
 		extraStmt := fmt.Sprintf("key (`%s`)", fname)
 		return "", false, &extraStmt, nil
 	},
+	"charsets": func(text string, fname string, ctx *fieldExec) (string, bool, *string, error) {
+		if text == "undef" {
+			return "", false, nil, nil
+		}
+		if !canCollate[ctx.dType()] {
+			return "", true, nil, nil
+		}
+		ctx.charset = text
+		return fmt.Sprintf("character set %s", text), false, nil, nil
+	},
+	"collations": func(text string, fname string, ctx *fieldExec) (string, bool, *string, error) {
+		if text == "undef" {
+			return "", false, nil, nil
+		}
+		if !canCollate[ctx.dType()] {
+			return "", true, nil, nil
+		}
+		charset := ctx.charset
+		if charset == "" {
+			charset = "undef"
+		}
+		err := checkCollation(charset, text)
+		if err == errIncompatibleCollation {
+			return "", true, nil, nil
+		}
+		if err != nil {
+			return "", false, nil, err
+		}
+		ctx.collation = text
+		if ctx.charset == "" {
+			ctx.charset, _ = collationCharset(text)
+		}
+		return fmt.Sprintf("collate %s", text), false, nil, nil
+	},
 	// "signed" is sign, other is "unsigned"
 	"sign": func(text string, fname string, ctx *fieldExec) (string, bool, *string, error) {
 		if ctx.canUnSign {
@@ -102,11 +173,12 @@ func (f *Fields) gen() ([]string, []*fieldExec, error) {
 	stmts := make([]string, 0, f.numbers)
 	extraStmts := make([]string, 0)
 	fieldExecs := make([]*fieldExec, 0, f.numbers)
+	names := make(uniqueNames)
 
 	err := f.traverse(func(cur []string) error {
-		fExec := &fieldExec{}
+		fExec := &fieldExec{nullable: true}
 
-		fname := fnamePrefix + "_" + strings.Join(cur, "_")
+		fname := names.get(fnamePrefix + "_" + strings.Join(fieldNameParts(f.fields, cur), "_"))
 		extraNum := 0
 
 		for i := range cur {
@@ -149,18 +221,165 @@ func (f *Fields) gen() ([]string, []*fieldExec, error) {
 	return stmts, fieldExecs, nil
 }
 
+// enum and set with declared members are named by a checksum of the type,
+// for example `enum('a','b')` -> `enum_3b1c9e2d`, undef charsets and
+// collations are omitted to keep the names of fields without them unchanged
+func fieldNameParts(fields []string, cur []string) []string {
+	parts := make([]string, 0, len(cur))
+	for i, val := range cur {
+		if (fields[i] == "charsets" || fields[i] == "collations") && val == "undef" {
+			continue
+		}
+		parts = append(parts, val)
+		if index := strings.Index(val, "("); index != -1 && strings.ContainsAny(val, `'"`) {
+			parts[len(parts)-1] = fmt.Sprintf("%s_%08x", strings.ToLower(val[:index]), crc32.ChecksumIEEE([]byte(val)))
+		}
+	}
+	return parts
+}
+
+// parse members of enum or set, like `('a','b','')`
+func parseMembers(text string) ([]string, error) {
+	s := strings.TrimSpace(text)
+	if !strings.HasPrefix(s, "(") {
+		return nil, fmt.Errorf("illegal members %s", text)
+	}
+	members := make([]string, 0)
+	i := 1
+	for {
+		for i < len(s) && s[i] == ' ' {
+			i++
+		}
+		if i >= len(s) || (s[i] != '\'' && s[i] != '"') {
+			return nil, fmt.Errorf("illegal members %s", text)
+		}
+		quote := s[i]
+		start := i + 1
+		for i = start; i < len(s); i++ {
+			if s[i] == '\\' {
+				i++
+			} else if s[i] == quote {
+				if i+1 < len(s) && s[i+1] == quote {
+					i++
+				} else {
+					break
+				}
+			}
+		}
+		if i >= len(s) {
+			return nil, fmt.Errorf("illegal members %s", text)
+		}
+		members = append(members, unescapeString(s[start:i], quote))
+		i++
+		for i < len(s) && s[i] == ' ' {
+			i++
+		}
+		if i < len(s) && s[i] == ',' {
+			i++
+			continue
+		}
+		if i < len(s) && s[i] == ')' {
+			return members, nil
+		}
+		return nil, fmt.Errorf("illegal members %s", text)
+	}
+}
+
 type fieldExec struct {
 	canUnSign bool
 	unsign    bool
 	name      string
 	// tp writen by user zz file
-	tp string
+	tp       string
+	nullable bool
+	pk       bool
+	indexed  bool
+	// members of enum or set
+	members []string
+	// character set and collation declared in zz, "" means default
+	charset   string
+	collation string
+	// what the sql_mode declared in zz accepts, see Data.withSqlMode
+	sqlMode generators.SqlMode
 }
 
+// type name without length and attributes, `int(11) unsigned` -> `int`
 func (f *fieldExec) dType() string {
-	index := strings.Index(f.tp, "(")
+	index := strings.IndexAny(f.tp, "( ")
 	if index == -1 {
 		return f.tp
 	}
 	return f.tp[:index]
 }
+
+var defaultMembers, _ = parseMembers(enumVals)
+
+// declared members of enum or set, a to z if they are not declared
+func (f *fieldExec) enumMembers() []string {
+	if f.members == nil {
+		return defaultMembers
+	}
+	return f.members
+}
+
+// the first argument of type, `binary(16)` -> 16, return defaul if there is no
+func (f *fieldExec) length(defaul int) int {
+	args := f.typeArgs()
+	if len(args) == 0 {
+		return defaul
+	}
+	return args[0]
+}
+
+// declared precision and scale of decimal and float(M,D), ok is false for other types
+func (f *fieldExec) precision() (int, int, bool) {
+	args := f.typeArgs()
+	switch f.dType() {
+	case "decimal", "numeric", "fixed":
+		precision := f.length(10)
+		if precision <= 0 {
+			precision = 10
+		}
+		scale := 0
+		if len(args) > 1 {
+			scale = args[1]
+		}
+		return precision, scale, true
+	case "float", "double", "real":
+		if len(args) == 2 {
+			return args[0], args[1], true
+		}
+	}
+	return 0, 0, false
+}
+
+// declared fractional seconds precision of datetime, timestamp and time,
+// ok is false for other types
+func (f *fieldExec) fsp() (int, bool) {
+	switch f.dType() {
+	case "datetime", "timestamp", "time":
+		return f.length(0), true
+	}
+	return 0, false
+}
+
+// numeric arguments of type, `decimal(10,2)` -> [10, 2]
+func (f *fieldExec) typeArgs() []int {
+	start := strings.Index(f.tp, "(")
+	if start == -1 {
+		return nil
+	}
+	end := strings.Index(f.tp[start:], ")")
+	if end == -1 {
+		return nil
+	}
+	args := make([]int, 0, 2)
+	for _, arg := range strings.Split(f.tp[start+1:start+end], ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(arg))
+		if err != nil {
+			return args
+		}
+		args = append(args, n)
+	}
+	return args
+}
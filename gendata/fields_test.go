@@ -28,3 +28,91 @@ fields = {
 		fmt.Println(stmt)
 	}*/
 }
+
+func TestJsonFields(t *testing.T) {
+	l, err := runLua(`
+fields = {
+    types = {'json', 'int'},
+    keys = {'undef', 'key'}
+}
+`)
+	assert.Equal(t, nil, err)
+
+	fields, err := newFields(l)
+	assert.Equal(t, nil, err)
+
+	stmts, fieldExecs, err := fields.gen()
+	assert.Equal(t, nil, err)
+
+	// json field is never indexed
+	assert.Equal(t, 3, len(fieldExecs))
+	assert.Equal(t, 4, len(stmts))
+	assert.Equal(t, "key (`col_int_key_signed`)", stmts[3])
+}
+
+func TestEnumFields(t *testing.T) {
+	l, err := runLua(`
+fields = {
+    types = {"enum('a','A','')", "set('x', 'it''s')", 'set', 'bit(3)'},
+    keys = {'undef'},
+}
+`)
+	assert.Equal(t, nil, err)
+
+	fields, err := newFields(l)
+	assert.Equal(t, nil, err)
+
+	stmts, fieldExecs, err := fields.gen()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 4, len(fieldExecs))
+
+	assert.Regexp(t, "^`col_enum_[0-9a-f]{8}_undef_signed` enum\\('a','A',''\\)", stmts[0])
+	assert.Equal(t, []string{"a", "A", ""}, fieldExecs[0].members)
+	assert.Equal(t, []string{"x", "it's"}, fieldExecs[1].members)
+	assert.Equal(t, 26, len(fieldExecs[2].enumMembers()))
+	assert.Equal(t, 3, fieldExecs[3].length(1))
+	assert.Equal(t, 1, fieldExecs[2].length(1))
+
+	_, err = parseMembers("('a',")
+	assert.NotNil(t, err)
+}
+
+func TestFieldCollations(t *testing.T) {
+	l, err := runLua(`
+fields = {
+    types = {'varchar(10)', 'int'},
+    keys = {'undef'},
+    charsets = {'utf8mb4', 'undef'},
+    collations = {'utf8mb4_bin', 'latin1_bin', 'undef'},
+}
+`)
+	assert.Equal(t, nil, err)
+
+	fields, err := newFields(l)
+	assert.Equal(t, nil, err)
+
+	stmts, fieldExecs, err := fields.gen()
+	assert.Equal(t, nil, err)
+
+	names := make([]string, 0, len(fieldExecs))
+	for _, f := range fieldExecs {
+		names = append(names, f.name)
+	}
+	assert.Equal(t, []string{
+		"col_varchar(10)_undef_utf8mb4_utf8mb4_bin_signed", "col_varchar(10)_undef_utf8mb4_signed",
+		"col_varchar(10)_undef_utf8mb4_bin_signed", "col_varchar(10)_undef_latin1_bin_signed",
+		"col_varchar(10)_undef_signed", "col_int_undef_signed",
+	}, names)
+	assert.Contains(t, stmts[0], "varchar(10) character set utf8mb4 collate utf8mb4_bin")
+
+	kf := NewKeyfun(nil, fieldExecs)
+	for i := 0; i < 10; i++ {
+		collation, err := kf["_collation"]()
+		assert.Equal(t, nil, err)
+		assert.Contains(t, []string{"utf8mb4_bin", "latin1_bin"}, collation)
+	}
+
+	collation, err := NewKeyfun(nil, nil)["_collation"]()
+	assert.Equal(t, nil, err)
+	assert.Contains(t, defaultCollations, collation)
+}
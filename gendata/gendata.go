@@ -58,49 +58,126 @@ func (z *ZzConfig) genDdls() ([]*tableStmt, []*fieldExec, error) {
 }
 
 func ByZz(zz string) ([]string, Keyfun, error) {
+	tables, keyf, err := TablesByZz(zz, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return flatTables(tables), keyf, nil
+}
+
+// TablesByZz is like ByZz, but returns sqls grouped by table,
+// every insert statement contains at most batchSize rows,
+// batchSize <= 0 means all rows of a table in one insert statement
+func TablesByZz(zz string, batchSize int) ([]*TableSqls, Keyfun, error) {
+	config, err := configByZz(zz)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return TablesByConfig(config, batchSize)
+}
+
+func configByZz(zz string) (*ZzConfig, error) {
 	// if zz is empty string, will use built-in default zz file
 	if zz == "" {
 		zzBs, err := resource.Asset("resource/default.zz.lua")
 		if err != nil {
-			return nil, nil, errors.Wrap(err, "default resource load fail")
+			return nil, errors.Wrap(err, "default resource load fail")
 		}
 		zz = string(zzBs)
 	}
 
 	l, err := runLua(zz)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	config, err := newZzConfig(l)
+	return newZzConfig(l)
+}
+
+func ByConfig(config *ZzConfig) ([]string, Keyfun, error) {
+	tables, keyf, err := TablesByConfig(config, 0)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	return ByConfig(config)
+	return flatTables(tables), keyf, nil
 }
 
-func ByConfig(config *ZzConfig) ([]string, Keyfun, error) {
+// TableSqls is the ddl and insert statements of one table,
+// statements of different tables are independent of each other,
+// so they can be executed in parallel
+type TableSqls struct {
+	Name    string
+	Ddl     string
+	Inserts []string
+}
+
+// all statements of the table, ddl comes first
+func (t *TableSqls) Sqls() []string {
+	sqls := make([]string, 0, len(t.Inserts)+1)
+	sqls = append(sqls, t.Ddl)
+	return append(sqls, t.Inserts...)
+}
+
+func flatTables(tables []*TableSqls) []string {
+	sqls := make([]string, 0, len(tables)*2)
+	for _, table := range tables {
+		sqls = append(sqls, table.Sqls()...)
+	}
+	return sqls
+}
+
+func TablesByConfig(config *ZzConfig, batchSize int) ([]*TableSqls, Keyfun, error) {
 	tableStmts, fieldExecs, err := config.genDdls()
 	if err != nil {
 		return nil, nil, err
 	}
 
-	recordGor := config.Data.getRecordGen(fieldExecs)
-	row := make([]string, len(fieldExecs))
-
-	sqls := make([]string, 0, len(tableStmts))
+	tables := make([]*TableSqls, 0, len(tableStmts))
 	for _, tableStmt := range tableStmts {
-		sqls = append(sqls, tableStmt.ddl)
-		valuesStmt := make([]string, 0, tableStmt.rowNum)
-		for i := 0; i < tableStmt.rowNum; i++ {
-			recordGor.oneRow(row)
-			valuesStmt = append(valuesStmt, wrapInDml(strconv.Itoa(i), row))
+		recordGor := config.Data.getRecordGen(fieldExecs, tableStmt.charset)
+		table := &TableSqls{Name: tableStmt.name, Ddl: tableStmt.ddl}
+		batch := batchSize
+		if batch <= 0 || batch > tableStmt.rowNum {
+			batch = tableStmt.rowNum
+		}
+		valuesStmt := make([]string, 0, batch)
+		err := genRows(recordGor, tableStmt, func(pk int, row []string) {
+			valuesStmt = append(valuesStmt, wrapInDml(strconv.Itoa(pk), row))
+			if len(valuesStmt) == batch {
+				table.Inserts = append(table.Inserts, wrapInInsert(tableStmt.name, valuesStmt))
+				valuesStmt = valuesStmt[:0]
+			}
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(valuesStmt) > 0 {
+			table.Inserts = append(table.Inserts, wrapInInsert(tableStmt.name, valuesStmt))
 		}
-		sqls = append(sqls, wrapInInsert(tableStmt.name, valuesStmt))
+		tables = append(tables, table)
 	}
 
-	return sqls, NewKeyfun(tableStmts, fieldExecs), nil
+	keyf := NewKeyfun(tableStmts, fieldExecs)
+	keyf.bindJson(config.Data.json)
+	return tables, keyf, nil
+}
+
+// generate all rows of table, values in row are sql literals,
+// row will be reused after handler returns
+func genRows(recordGor recordGen, table *tableStmt, handler func(pk int, row []string)) error {
+	row := make([]string, len(recordGor.gens))
+	for i := 0; i < table.rowNum; i++ {
+		recordGor.setRow(table.name, i)
+		recordGor.oneRow(row)
+		if err := recordGor.err(); err != nil {
+			return err
+		}
+		handler(i, row)
+	}
+	return nil
 }
 
 type dbDriverError struct {
@@ -112,73 +189,20 @@ func (e *dbDriverError) Error() string {
 	return fmt.Sprintf("%s - %s", e.msg, e.driver)
 }
 
-// generate keyfun by db, it assumes all tables have the same fields
+// generate keyfun by the schema of db, see LoadSchema
 func ByDb(db *sql.DB, dbms string) (Keyfun, error) {
-	// support different databases
-	var rows *sql.Rows
-	var err error
-
-	if dbms == "sqlite3" {
-		rows, err = db.Query("SELECT name FROM sqlite_master WHERE type='table';")
-	} else if dbms == "mysql" {
-		rows, err = db.Query("show tables")
-	} else {
-		err = &dbDriverError{dbms, "Cannot retrieve the tables."}
-	}
-
+	schema, err := LoadSchema(db, dbms)
 	if err != nil {
 		return nil, err
 	}
 
-	tableStmts := make([]*tableStmt, 0)
-
-	for rows.Next() {
-		var tableName string
-		err := rows.Scan(&tableName)
-		if err != nil {
-			return nil, err
-		}
-		tableStmts = append(tableStmts, &tableStmt{name: tableName})
-	}
-	rows.Close()
-
-	fieldExecs := make([]*fieldExec, 0)
-
-	if len(tableStmts) > 0 {
-		if dbms == "sqlite3" {
-			rows, err = db.Query(fmt.Sprintf("PRAGMA table_info('%s');", tableStmts[0].name))
-		} else if dbms == "mysql" {
-			rows, err = db.Query(fmt.Sprintf("desc %s", tableStmts[0].name))
-		} else {
-			err = &dbDriverError{dbms, "Cannot retrieve the fields"}
-		}
-
-		if err != nil {
-			return nil, err
-		}
-
-		for rows.Next() {
-			var fieldName, fieldType string
-			if dbms == "sqlite3" {
-				err = rows.Scan(&sql.RawBytes{}, &fieldName,
-					&fieldType, &sql.RawBytes{},
-					&sql.RawBytes{}, &sql.RawBytes{})
-			} else if dbms == "mysql" {
-				err = rows.Scan(&fieldName, &fieldType,
-					&sql.RawBytes{}, &sql.RawBytes{},
-					&sql.RawBytes{}, &sql.RawBytes{})
-			}
-
-			if err != nil {
-				return nil, err
-			}
-
-			fieldExecs = append(fieldExecs, &fieldExec{name: fieldName, tp: fieldType})
-		}
-
-	}
+	return BySchema(schema), nil
+}
 
-	return NewKeyfun(tableStmts, fieldExecs), nil
+// generate keyfun by schema, field keyfuns choose fields from the
+// table chosen by `_table` or `_table_partitioned` last time
+func BySchema(schema *Schema) Keyfun {
+	return NewKeyfun(schema.tableStmts(), nil)
 }
 
 const insertTemp = "insert into %s values %s"
@@ -200,26 +224,89 @@ func wrapInDml(pk string, data []string) string {
 	return buf.String()
 }
 
+// field classes, there are `_field_<class>`, `_field_<class>_list`
+// and `_value_<class>` keyfuns for every class
 const (
-	fInt = iota
-	fChar
+	fInt      = "int"
+	fChar     = "char"
+	fDecimal  = "decimal"
+	fFloat    = "float"
+	fTemporal = "temporal"
+	fDate     = "date"
+	fEnum     = "enum"
+	fBlob     = "blob"
+	fJson     = "json"
 )
 
-var fClass = map[string]int{
-	"char":      fChar,
-	"varchar":   fChar,
-	"binary":    fChar,
-	"varbinary": fChar,
-	"integer":   fInt,
-	"int":       fInt,
-	"smallint":  fInt,
-	"tinyint":   fInt,
-	"mediumint": fInt,
-	"bigint":    fInt,
+var fClasses = []string{fInt, fChar, fDecimal, fFloat, fTemporal, fDate, fEnum, fBlob, fJson}
+
+// a type may belong to several classes, such as `date` is both temporal and date
+var fClass = map[string][]string{
+	"char":       {fChar},
+	"varchar":    {fChar},
+	"binary":     {fChar},
+	"varbinary":  {fChar},
+	"integer":    {fInt},
+	"int":        {fInt},
+	"smallint":   {fInt},
+	"tinyint":    {fInt},
+	"mediumint":  {fInt},
+	"bigint":     {fInt},
+	"decimal":    {fDecimal},
+	"numeric":    {fDecimal},
+	"fixed":      {fDecimal},
+	"float":      {fFloat},
+	"double":     {fFloat},
+	"real":       {fFloat},
+	"date":       {fTemporal, fDate},
+	"datetime":   {fTemporal, fDate},
+	"timestamp":  {fTemporal, fDate},
+	"time":       {fTemporal},
+	"year":       {fTemporal},
+	"enum":       {fEnum},
+	"set":        {fEnum},
+	"tinyblob":   {fBlob},
+	"blob":       {fBlob},
+	"mediumblob": {fBlob},
+	"longblob":   {fBlob},
+	"tinytext":   {fBlob},
+	"text":       {fBlob},
+	"mediumtext": {fBlob},
+	"longtext":   {fBlob},
+	"json":       {fJson},
+}
+
+// generators of literals which can be compared with fields of the class
+var classValues = map[string][]string{
+	fInt:      {"tinyint", "smallint", "int"},
+	fChar:     {"letter", "english", "char"},
+	fDecimal:  {"decimal"},
+	fFloat:    {"float"},
+	fTemporal: {"date", "time", "datetime", "timestamp", "year"},
+	fDate:     {"date", "datetime"},
+	fEnum:     {"letter"},
+	fBlob:     {"letter", "english"},
+	fJson:     {"json"},
 }
 
 type Keyfun map[string]func() (string, error)
 
+// bindJson makes json keyfuns use the json options of zz, so that json
+// paths in yy match the generated documents
+func (k Keyfun) bindJson(json *generators.JsonOptions) {
+	generators.Traverse(func(name string, generator generators.Generator) {
+		if bound := json.Bind(generator); bound != generator {
+			k["_"+name] = func() (string, error) {
+				return bound.Gen(), nil
+			}
+		}
+	})
+	valueGen := composeFromGenName(classValues[fJson], json)
+	k["_value_"+fJson] = func() (string, error) {
+		return valueGen.Gen(), nil
+	}
+}
+
 func joinFields(fields []*fieldExec) string {
 	strBuf := bytes.Buffer{}
 
@@ -240,81 +327,163 @@ func joinFields(fields []*fieldExec) string {
 	return strBuf.String()
 }
 
-var field_invariant = ""
+func randField(fields []*fieldExec, kind string) (string, error) {
+	if len(fields) == 0 {
+		return "", fmt.Errorf("there is no %sfields", kind)
+	}
+	return "`" + fields[rand.Intn(len(fields))].name + "`", nil
+}
 
-func NewKeyfun(tables []*tableStmt, fields []*fieldExec) Keyfun {
-	fieldsInt := make([]*fieldExec, 0)
-	fieldsChar := make([]*fieldExec, 0)
+func listFields(fields []*fieldExec, kind string) (string, error) {
+	if len(fields) == 0 {
+		return "", fmt.Errorf("there is no %sfields", kind)
+	}
+	return joinFields(fields), nil
+}
+
+// fields of one table classified for keyfuns
+type classifiedFields struct {
+	all      []*fieldExec
+	classes  map[string][]*fieldExec
+	nullable []*fieldExec
+	// include extra fields
+	indexed []*fieldExec
+	pk      []*fieldExec
+}
+
+func classifyFields(fields []*fieldExec, extraFields []*fieldExec) *classifiedFields {
+	c := &classifiedFields{all: fields, classes: make(map[string][]*fieldExec)}
 
 	for _, fieldExec := range fields {
-		if class, ok := fClass[fieldExec.dType()]; ok {
-			switch class {
-			case fInt:
-				fieldsInt = append(fieldsInt, fieldExec)
-			case fChar:
-				fieldsChar = append(fieldsChar, fieldExec)
+		for _, class := range fClass[fieldExec.dType()] {
+			c.classes[class] = append(c.classes[class], fieldExec)
+		}
+		if fieldExec.nullable {
+			c.nullable = append(c.nullable, fieldExec)
+		}
+	}
+
+	for _, fieldExec := range append(extraFields, fields...) {
+		if fieldExec.indexed || fieldExec.pk {
+			c.indexed = append(c.indexed, fieldExec)
+		}
+		if fieldExec.pk {
+			c.pk = append(c.pk, fieldExec)
+		}
+	}
+
+	return c
+}
+
+// collations declared in zz, defaultCollations if there is no one
+func usedCollations(tables []*tableStmt, fields []*fieldExec) []string {
+	collations := make([]string, 0)
+	seen := make(map[string]bool)
+	add := func(collation string) {
+		if collation != "" && !seen[collation] {
+			seen[collation] = true
+			collations = append(collations, collation)
+		}
+	}
+	for _, table := range tables {
+		add(table.collation)
+		for _, field := range table.fields {
+			add(field.collation)
+		}
+	}
+	for _, field := range fields {
+		add(field.collation)
+	}
+
+	if len(collations) == 0 {
+		return defaultCollations
+	}
+	return collations
+}
+
+// NewKeyfun generates keyfuns by tables and fields. If a table has
+// its own fields, field keyfuns use fields of the table chosen by `_table`
+// last time (the first table before any choice), otherwise use fields
+func NewKeyfun(tables []*tableStmt, fields []*fieldExec) Keyfun {
+	shared := classifyFields(fields, nil)
+	classified := make(map[*tableStmt]*classifiedFields)
+	partitioned := make([]*tableStmt, 0)
+	for _, table := range tables {
+		if table.fields != nil || len(table.extraFields) > 0 {
+			tableFields := table.fields
+			if tableFields == nil {
+				tableFields = fields
 			}
+			classified[table] = classifyFields(tableFields, table.extraFields)
+		}
+		if table.partitioned {
+			partitioned = append(partitioned, table)
 		}
 	}
 
+	collations := usedCollations(tables, fields)
+
+	var curTable *tableStmt
+	curFields := func() *classifiedFields {
+		table := curTable
+		if table == nil && len(tables) > 0 {
+			table = tables[0]
+		}
+		if c, ok := classified[table]; ok {
+			return c
+		}
+		return shared
+	}
+
 	m := map[string]func() (string, error){
 		"_table": func() (string, error) {
 			if len(tables) == 0 {
 				return "", errors.New("there is no table")
 			}
-			return tables[rand.Intn(len(tables))].name, nil
+			curTable = tables[rand.Intn(len(tables))]
+			return curTable.name, nil
 		},
-		"_field": func() (string, error) {
-			if len(fields) == 0 {
-				return "", errors.New("there is no fields")
+		"_table_partitioned": func() (string, error) {
+			if len(partitioned) == 0 {
+				return "", errors.New("there is no partitioned table")
 			}
-			return "`" + fields[rand.Intn(len(fields))].name + "`", nil
+			curTable = partitioned[rand.Intn(len(partitioned))]
+			return curTable.name, nil
 		},
-
-		"_field_invariant": func() (string, error) {
-			if len(fields) == 0 {
-				return "", errors.New("there is no fields")
-			}
-			// set the invariant
-			if len(field_invariant) == 0 {
-				field_invariant = "`" + fields[rand.Intn(len(fields))].name + "`"
-			}
-			// use the invariant
-			return field_invariant, nil
+		"_field": func() (string, error) {
+			return randField(curFields().all, "")
 		},
-
-		"_field_int": func() (string, error) {
-			if len(fieldsInt) == 0 {
-				return "", errors.New("there is no int fields")
-			}
-			return "`" + fieldsInt[rand.Intn(len(fieldsInt))].name + "`", nil
+		"_field_list": func() (s string, e error) {
+			return listFields(curFields().all, "")
 		},
-		"_field_int_list": func() (s string, e error) {
-			if len(fieldsInt) == 0 {
-				return "", errors.New("there is no int fields")
-			}
-			return joinFields(fieldsInt), nil
+		"_field_indexed": func() (string, error) {
+			return randField(curFields().indexed, "indexed ")
 		},
-		"_field_char": func() (string, error) {
-			if len(fieldsChar) == 0 {
-				return "", errors.New("there is no char fields")
-			}
-			return "`" + fieldsChar[rand.Intn(len(fieldsChar))].name + "`", nil
+		"_field_pk": func() (string, error) {
+			return randField(curFields().pk, "primary key ")
 		},
-		"_field_char_list": func() (s string, e error) {
-			if len(fieldsChar) == 0 {
-				return "", errors.New("there is no char fields")
-			}
-			return joinFields(fieldsChar), nil
+		"_field_nullable": func() (string, error) {
+			return randField(curFields().nullable, "nullable ")
 		},
-		"_field_list": func() (s string, e error) {
-			if len(fields) == 0 {
-				return "", errors.New("there is no char fields")
-			}
-			return joinFields(fields), nil
+		"_collation": func() (string, error) {
+			return collations[rand.Intn(len(collations))], nil
 		},
 	}
 
+	for _, class := range fClasses {
+		class := class
+		m["_field_"+class] = func() (string, error) {
+			return randField(curFields().classes[class], class+" ")
+		}
+		m["_field_"+class+"_list"] = func() (string, error) {
+			return listFields(curFields().classes[class], class+" ")
+		}
+		valueGen := composeFromGenName(classValues[class], nil)
+		m["_value_"+class] = func() (string, error) {
+			return valueGen.Gen(), nil
+		}
+	}
+
 	// port from generators
 	// digit -> _digit
 	generators.Traverse(func(name string, generator generators.Generator) {
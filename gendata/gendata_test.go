@@ -1,8 +1,10 @@
 package gendata
 
 import (
+	"database/sql"
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
+	"strings"
 	"testing"
 )
 
@@ -53,9 +55,39 @@ data = {
 	})
 
 	t.Run("gen sqls", func(t *testing.T) {
-		sqls, _, err := ByConfig(config)
+		sqls, keyf, err := ByConfig(config)
 		assert.Equal(t, nil, err)
 		assert.Equal(t, config.Tables.numbers*2, len(sqls))
+
+		assertMustEqual(t, "`pk`", keyf["_field_pk"])
+		for i := 0; i < 10; i++ {
+			tname, err := keyf["_table_partitioned"]()
+			assert.Equal(t, nil, err)
+			assert.Equal(t, false, strings.HasSuffix(tname, "_undef"))
+
+			indexed, err := keyf["_field_indexed"]()
+			assert.Equal(t, nil, err)
+			assert.True(t, indexed == "`pk`" || strings.Contains(indexed, "_key_"))
+		}
+	})
+
+	t.Run("gen sqls in batch", func(t *testing.T) {
+		tables, _, err := TablesByConfig(config, 7)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, config.Tables.numbers, len(tables))
+
+		for _, table := range tables {
+			assert.True(t, strings.HasPrefix(table.Ddl, "create table "+table.Name))
+			rows := 0
+			for _, insert := range table.Inserts {
+				num := strings.Count(insert, "),(") + 1
+				assert.True(t, num <= 7)
+				rows += num
+			}
+			// rows of tables are 10, 20 or 30
+			assert.Equal(t, (rows+6)/7, len(table.Inserts))
+			assert.Contains(t, []int{10, 20, 30}, rows)
+		}
 	})
 
 }
@@ -71,40 +103,64 @@ func TestByDb(t *testing.T) {
 		tableSet[tbl] = true
 	}
 
-	rows := sqlmock.NewRows([]string{"Tables_in_test"})
+	rows := sqlmock.NewRows([]string{"table_name"})
 	for _, tname := range tableOrders {
 		rows.AddRow(tname)
 	}
 
-	mock.ExpectQuery("show tables").
+	mock.ExpectQuery("select table_name from information_schema.tables").
 		WillReturnRows(rows)
 
 	type fieldInfo struct {
-		tp string
+		tp       string
+		nullable string
+		key      string
 	}
 
-	infoOrders := []string{"v1", "v2"}
+	infoOrders := []string{"v1", "v2", "v3"}
 
 	infos := map[string]*fieldInfo{
 		"v1": {
-			tp: "int(11)",
+			tp:       "int(11)",
+			nullable: "NO",
+			key:      "PRI",
 		},
 		"v2": {
-			tp: "varchar(255)",
+			tp:       "varchar(255)",
+			nullable: "YES",
+			key:      "MUL",
+		},
+		"v3": {
+			tp:       "bigint unsigned",
+			nullable: "NO",
 		},
 	}
 
-	fRows := sqlmock.NewRows([]string{"Field", "Type", "Null",
-		"Key", "Default", "Extra"})
+	fRows := sqlmock.NewRows([]string{"table_name", "column_name", "column_type",
+		"is_nullable", "column_key"})
 
-	for _, infoName := range infoOrders {
-		info := infos[infoName]
-		fRows.AddRow(infoName, info.tp, "YES", "", nil, "")
+	for _, tname := range tableOrders {
+		for _, infoName := range infoOrders {
+			info := infos[infoName]
+			fRows.AddRow(tname, infoName, info.tp, info.nullable, info.key)
+		}
 	}
 
-	mock.ExpectQuery("desc table1").
+	mock.ExpectQuery("select table_name, column_name, column_type, is_nullable, column_key").
 		WillReturnRows(fRows)
 
+	iRows := sqlmock.NewRows([]string{"table_name", "index_name", "non_unique", "column_name"})
+	for _, tname := range tableOrders {
+		iRows.AddRow(tname, "PRIMARY", 0, "v1")
+		iRows.AddRow(tname, "k2", 1, "v2")
+	}
+
+	mock.ExpectQuery("select table_name, index_name, non_unique, column_name").
+		WillReturnRows(iRows)
+
+	mock.ExpectQuery("select distinct table_name from information_schema.partitions").
+		WillReturnRows(sqlmock.NewRows([]string{"table_name"}).AddRow("table2"))
+
 	kf, err := ByDb(db, "mysql")
 	assert.Equal(t, nil, err)
 
@@ -123,15 +179,72 @@ func TestByDb(t *testing.T) {
 			return ok
 		})
 
-		assertMustEqual(t, "`v1`", kf["_field_int"])
-
 		assertMustEqual(t, "`v2`", kf["_field_char"])
+
+		assertMustEqual(t, "`v2`", kf["_field_nullable"])
+		assertMustEqual(t, "`v1`", kf["_field_pk"])
+		assertMustEqual(t, "table2", kf["_table_partitioned"])
 	}
 
-	assertMustEqual(t, "`v1`,`v2`", kf["_field_list"])
+	assertMustEqual(t, "`v1`,`v2`,`v3`", kf["_field_list"])
 
-	assertMustEqual(t, "`v1`", kf["_field_int_list"])
+	assertMustEqual(t, "`v1`,`v3`", kf["_field_int_list"])
 	assertMustEqual(t, "`v2`", kf["_field_char_list"])
+
+	for i := 0; i < 20; i++ {
+		res, err := kf["_field_indexed"]()
+		assert.Equal(t, nil, err)
+		assert.Contains(t, []string{"`v1`", "`v2`"}, res)
+	}
+}
+
+func TestLoadSqliteSchema(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	assert.Equal(t, nil, err)
+	defer db.Close()
+	// every connection has its own memory database
+	db.SetMaxOpenConns(1)
+
+	ddls := []string{
+		"create table t1 (id integer primary key, a int not null, b varchar(10))",
+		"create index idx_ab on t1 (a, b)",
+		"create table t2 (c text)",
+	}
+	for _, ddl := range ddls {
+		_, err := db.Exec(ddl)
+		assert.Equal(t, nil, err)
+	}
+
+	schema, err := LoadSchema(db, "sqlite3")
+	assert.Equal(t, nil, err)
+
+	assert.Equal(t, 2, len(schema.Tables))
+	t1 := schema.Tables[0]
+	assert.Equal(t, "t1", t1.Name)
+	assert.Equal(t, 3, len(t1.Columns))
+	assert.Equal(t, true, t1.column("id").Pk)
+	assert.Equal(t, false, t1.column("a").Nullable)
+	assert.Equal(t, true, t1.column("a").Indexed)
+	assert.Equal(t, true, t1.column("b").Indexed)
+	assert.Equal(t, 1, len(t1.Indexes))
+	assert.Equal(t, []string{"a", "b"}, t1.Indexes[0].Columns)
+
+	t2 := schema.Tables[1]
+	assert.Equal(t, "t2", t2.Name)
+	assert.Equal(t, true, t2.column("c").Nullable)
+	assert.Equal(t, false, t2.column("c").Indexed)
+
+	kf := BySchema(schema)
+	// fields come from the table chosen last time
+	for i := 0; i < 20; i++ {
+		tname, err := kf["_table"]()
+		assert.Equal(t, nil, err)
+		if tname == "t2" {
+			assertMustEqual(t, "`c`", kf["_field_list"])
+		} else {
+			assertMustEqual(t, "`id`,`a`,`b`", kf["_field_list"])
+		}
+	}
 }
 
 func assertMustEqual(t *testing.T, expected string, kf func() (string, error)) {
@@ -139,3 +252,34 @@ func assertMustEqual(t *testing.T, expected string, kf func() (string, error)) {
 	assert.Equal(t, nil, err)
 	assert.Equal(t, expected, res)
 }
+
+func TestClassKeyfun(t *testing.T) {
+	fields := []*fieldExec{
+		{name: "c_decimal", tp: "decimal(10,2)"},
+		{name: "c_double", tp: "double"},
+		{name: "c_date", tp: "date"},
+		{name: "c_time", tp: "time(3)"},
+		{name: "c_set", tp: "set('a','b')"},
+		{name: "c_text", tp: "text"},
+		{name: "c_json", tp: "json"},
+	}
+
+	kf := NewKeyfun([]*tableStmt{{name: "t"}}, fields)
+
+	assertMustEqual(t, "`c_decimal`", kf["_field_decimal"])
+	assertMustEqual(t, "`c_double`", kf["_field_float"])
+	assertMustEqual(t, "`c_date`", kf["_field_date"])
+	assertMustEqual(t, "`c_date`,`c_time`", kf["_field_temporal_list"])
+	assertMustEqual(t, "`c_set`", kf["_field_enum"])
+	assertMustEqual(t, "`c_text`", kf["_field_blob"])
+	assertMustEqual(t, "`c_json`", kf["_field_json_list"])
+
+	_, err := kf["_field_int"]()
+	assert.Equal(t, "there is no int fields", err.Error())
+
+	for _, class := range fClasses {
+		res, err := kf["_value_"+class]()
+		assert.Equal(t, nil, err)
+		assert.NotEqual(t, "", res)
+	}
+}
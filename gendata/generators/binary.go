@@ -0,0 +1,112 @@
+package generators
+
+import (
+	"encoding/hex"
+	"math/rand"
+	"strings"
+)
+
+// random bit value literal with at most width bits, like b'101'
+type Bit struct {
+	width int
+}
+
+func NewBit(width int) *Bit {
+	if width <= 0 {
+		width = 1
+	}
+	return &Bit{width}
+}
+
+func (b *Bit) Gen() string {
+	bits := make([]byte, randInRange(1, b.width))
+	for i := range bits {
+		bits[i] = byte('0' + rand.Intn(2))
+	}
+	return `b'` + string(bits) + `'`
+}
+
+// random hex literal of binary bytes, like x'00ff3a',
+// fixed means it always has length bytes
+type Binary struct {
+	length int
+	fixed  bool
+}
+
+func NewBinary(length int, fixed bool) *Binary {
+	if length <= 0 {
+		length = 1
+	}
+	return &Binary{length, fixed}
+}
+
+func (b *Binary) Gen() string {
+	n := b.length
+	if !b.fixed {
+		n = randInRange(0, b.length)
+	}
+	bs := make([]byte, n)
+	for i := range bs {
+		// make zero bytes and 0xff more frequent
+		switch rand.Intn(4) {
+		case 0:
+			bs[i] = 0
+		case 1:
+			bs[i] = 0xff
+		default:
+			bs[i] = byte(rand.Intn(256))
+		}
+	}
+	return `x'` + hex.EncodeToString(bs) + `'`
+}
+
+var memberEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+
+// random member of an enum, or a random combination of members of a set
+// like 'a,c', members must not contain ','
+type Member struct {
+	members []string
+	set     bool
+}
+
+func NewMember(members []string, set bool) *Member {
+	return &Member{members, set}
+}
+
+func (m *Member) Gen() string {
+	if len(m.members) == 0 {
+		return `''`
+	}
+	if !m.set {
+		return `'` + memberEscaper.Replace(m.members[rand.Intn(len(m.members))]) + `'`
+	}
+
+	perm := rand.Perm(len(m.members))[:randInRange(0, len(m.members))]
+	picked := make([]string, 0, len(perm))
+	for _, i := range perm {
+		picked = append(picked, m.members[i])
+	}
+	return `'` + memberEscaper.Replace(strings.Join(picked, ",")) + `'`
+}
+
+// random numeric index of enum members (1 based) or bit mask of set members,
+// it may be out of range, such as 0 or len(members)+1 for enum
+type MemberIndex struct {
+	num int
+	set bool
+}
+
+func NewMemberIndex(num int, set bool) *MemberIndex {
+	return &MemberIndex{num, set}
+}
+
+func (m *MemberIndex) Gen() string {
+	if !m.set {
+		return newInt(0, m.num+1, "").Gen()
+	}
+	// set has at most 64 members
+	if m.num >= 62 {
+		return newInt(0, -1, "").Gen()
+	}
+	return newInt(0, 1<<uint(m.num+1)-1, "").Gen()
+}
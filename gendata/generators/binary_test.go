@@ -0,0 +1,62 @@
+package generators
+
+import (
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestBit(t *testing.T) {
+	bitPattern := regexp.MustCompile(`^b'[01]{1,5}'$`)
+	b := NewBit(5)
+	for i := 0; i < 100; i++ {
+		assert.Regexp(t, bitPattern, b.Gen())
+	}
+}
+
+func TestBinary(t *testing.T) {
+	fixed := NewBinary(4, true)
+	varying := NewBinary(4, false)
+	for i := 0; i < 100; i++ {
+		res := fixed.Gen()
+		assert.Regexp(t, `^x'[0-9a-f]{8}'$`, res)
+
+		res = varying.Gen()
+		assert.Regexp(t, `^x'([0-9a-f]{2}){0,4}'$`, res)
+	}
+}
+
+func TestMember(t *testing.T) {
+	members := []string{"a", "A", "", "it's"}
+
+	enum := NewMember(members, false)
+	set := NewMember(members[:2], true)
+	multi := false
+	for i := 0; i < 100; i++ {
+		res := enum.Gen()
+		assert.Contains(t, []string{`'a'`, `'A'`, `''`, `'it\'s'`}, res)
+
+		res = set.Gen()
+		assert.Contains(t, []string{`''`, `'a'`, `'A'`, `'a,A'`, `'A,a'`}, res)
+		if strings.Contains(res, ",") {
+			multi = true
+		}
+	}
+	assert.True(t, multi)
+}
+
+func TestMemberIndex(t *testing.T) {
+	enum := NewMemberIndex(3, false)
+	set := NewMemberIndex(3, true)
+	for i := 0; i < 100; i++ {
+		idx, err := strconv.Atoi(enum.Gen())
+		assert.Equal(t, nil, err)
+		assert.True(t, idx >= 0 && idx <= 4)
+
+		mask, err := strconv.Atoi(set.Gen())
+		assert.Equal(t, nil, err)
+		assert.True(t, mask >= 0 && mask < 16)
+	}
+}
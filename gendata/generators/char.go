@@ -4,19 +4,27 @@ import "math/rand"
 
 var randChars = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
 
+// random letters with length in [min, max]
 type Char struct {
-	length int
+	min int
+	max int
 }
 
 func NewChar(length int) *Char {
-	return &Char{length:length}
+	return &Char{min: length, max: length}
+}
+
+func NewCharRange(min int, max int) *Char {
+	if max < min {
+		min, max = max, min
+	}
+	return &Char{min: min, max: max}
 }
 
 func (c *Char) Gen() string {
-	b := make([]rune, c.length)
+	b := make([]rune, randInRange(c.min, c.max))
 	for i := range b {
 		b[i] = randChars[rand.Intn(len(randChars))]
 	}
-	return `"` + string(b) + `"`
+	return quoteString(string(b))
 }
-
@@ -2,14 +2,77 @@ package generators
 
 import (
 	"fmt"
+	"math/rand"
 	"strconv"
 )
 
+// random decimal, zero precision means numbers like 12.0123
 type Decimal struct {
+	precision int
+	scale     int
+	// probability of having more fractional digits than scale
+	overflow float64
+	unsigned bool
 }
 
-func (*Decimal) Gen() string {
-	return strconv.Itoa(randInRange(0, 100)) +
-		"." + fmt.Sprintf("%04d", randInRange(0, 1999))
+func NewDecimal(precision int, scale int) *Decimal {
+	return &Decimal{precision, scale, 0, false}
 }
 
+// NewOverflowDecimal is like NewDecimal, but at the ratio overflow it generates
+// 1 to 3 more fractional digits than scale, which are rounded when inserted.
+// Unsigned means only non-negative values are generated
+func NewOverflowDecimal(precision int, scale int, overflow float64, unsigned bool) *Decimal {
+	return &Decimal{precision, scale, overflow, unsigned}
+}
+
+func randDigits(n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte('0' + rand.Intn(10))
+	}
+	return string(b)
+}
+
+// 0 in most cases, 1 to 3 at ratio
+func overflowDigits(ratio float64) int {
+	if ratio > 0 && rand.Float64() < ratio {
+		return randInRange(1, 3)
+	}
+	return 0
+}
+
+func (d *Decimal) Gen() string {
+	if d.precision == 0 {
+		return strconv.Itoa(randInRange(0, 100)) +
+			"." + fmt.Sprintf("%04d", randInRange(0, 1999))
+	}
+
+	// fill all integer digits sometimes
+	intDigits := d.precision - d.scale
+	if rand.Intn(4) != 0 {
+		intDigits = randInRange(0, intDigits)
+	}
+	res := "0"
+	if intDigits > 0 {
+		res = strconv.Itoa(randInRange(1, 9)) + randDigits(intDigits-1)
+	}
+	if d.scale > 0 {
+		res += "." + randDigits(d.scale)
+	}
+	if n := overflowDigits(d.overflow); n > 0 {
+		if d.scale == 0 {
+			res += "."
+		}
+		extra := randDigits(n)
+		// rounding up a full integer part like 999.99 may be out of range
+		if intDigits == d.precision-d.scale {
+			extra = strconv.Itoa(rand.Intn(5)) + extra[1:]
+		}
+		res += extra
+	}
+	if !d.unsigned && rand.Intn(2) == 0 {
+		res = "-" + res
+	}
+	return res
+}
@@ -2,6 +2,8 @@ package generators
 
 import (
 	"fmt"
+	"github.com/stretchr/testify/assert"
+	"strings"
 	"testing"
 )
 
@@ -11,3 +13,33 @@ func TestDecimal(t *testing.T)  {
 		fmt.Println(d.Gen())
 	}
 }
+
+func TestOverflowDecimal(t *testing.T) {
+	d := NewOverflowDecimal(40, 20, 0.5, false)
+	full, overflow := false, false
+	for i := 0; i < 200; i++ {
+		v := strings.TrimPrefix(d.Gen(), "-")
+		parts := strings.Split(v, ".")
+		assert.True(t, len(parts[0]) <= 20, v)
+		assert.True(t, len(parts[1]) >= 20 && len(parts[1]) <= 23, v)
+		full = full || len(parts[0]) == 20
+		overflow = overflow || len(parts[1]) > 20
+	}
+	assert.True(t, full)
+	assert.True(t, overflow)
+
+	d = NewOverflowDecimal(3, 2, 1, true)
+	for i := 0; i < 100; i++ {
+		v := d.Gen()
+		assert.Regexp(t, `^\d\.\d{3,5}$`, v)
+		// a full integer part is never rounded up out of range
+		if v[0] == '9' {
+			assert.True(t, v[4] < '5', v)
+		}
+	}
+
+	d = NewDecimal(3, 0)
+	for i := 0; i < 50; i++ {
+		assert.Regexp(t, `^-?(0|[1-9]\d{0,2})$`, d.Gen())
+	}
+}
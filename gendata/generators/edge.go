@@ -0,0 +1,206 @@
+package generators
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// signed min and max of integer types
+var intRanges = map[string][2]string{
+	"tinyint":   {"-128", "127"},
+	"smallint":  {"-32768", "32767"},
+	"mediumint": {"-8388608", "8388607"},
+	"int":       {"-2147483648", "2147483647"},
+	"integer":   {"-2147483648", "2147483647"},
+	"bigint":    {"-9223372036854775808", "9223372036854775807"},
+}
+
+// unsigned max of integer types
+var uintMax = map[string]string{
+	"tinyint":   "255",
+	"smallint":  "65535",
+	"mediumint": "16777215",
+	"int":       "4294967295",
+	"integer":   "4294967295",
+	"bigint":    "18446744073709551615",
+}
+
+var floatEdges = []string{"3.402823466e+38", "-3.402823466e+38", "1.175494351e-38", "-1.175494351e-38"}
+
+var doubleEdges = []string{"1.7976931348623157e308", "-1.7976931348623157e308",
+	"2.2250738585072014e-308", "-2.2250738585072014e-308"}
+
+// boundary values of temporal types, max values are built with the fractional
+// seconds of fsp, so that they are not rounded out of range. Zero dates are only
+// valid if the sql_mode is not strict or has no NO_ZERO_DATE
+func temporalEdges(tp string, fsp int, zeroDates bool) []string {
+	frac := ""
+	if fsp > 6 {
+		fsp = 6
+	}
+	if fsp > 0 {
+		frac = "." + strings.Repeat("9", fsp)
+	}
+
+	var values []string
+	zero := ""
+	switch tp {
+	case "date":
+		values = []string{"'1000-01-01'", "'9999-12-31'", "'2000-02-29'", "'2020-02-29'"}
+		zero = "'0000-00-00'"
+	case "datetime":
+		values = []string{"'1000-01-01 00:00:00'", "'9999-12-31 23:59:59" + frac + "'",
+			"'2020-02-29 23:59:59" + frac + "'", "'2019-12-31 23:59:59" + frac + "'"}
+		zero = "'0000-00-00 00:00:00'"
+	case "timestamp":
+		values = []string{"'1970-01-01 00:00:01'", "'2038-01-19 03:14:07" + frac + "'",
+			"'2020-02-29 00:00:00'", "'2019-12-31 23:59:59" + frac + "'"}
+		zero = "'0000-00-00 00:00:00'"
+	case "time":
+		return []string{"'-838:59:59'", "'838:59:59'", "'23:59:59" + frac + "'", "'00:00:00'",
+			"'-00:00:01'", "'-23:59:59" + frac + "'"}
+	case "year":
+		return []string{"1901", "2155", "0", "'0000'", "2000"}
+	default:
+		return nil
+	}
+
+	if zeroDates {
+		values = append(values, zero)
+	}
+	return values
+}
+
+// default max length of string types without declared length
+var textLength = map[string]int{
+	"tinytext":   255,
+	"text":       1024,
+	"mediumtext": 1024,
+	"longtext":   1024,
+	"tinyblob":   255,
+	"blob":       1024,
+	"mediumblob": 1024,
+	"longblob":   1024,
+}
+
+// SqlMode is what the sql_mode of servers accepts, edge values
+// rejected by it are not generated
+type SqlMode struct {
+	// zero dates like '0000-00-00' are valid
+	ZeroDates bool
+	// invalid values like '' of enum are converted instead of rejected
+	NonStrict bool
+}
+
+// boundary values of a type, like min and max integers
+type Edge struct {
+	values []string
+}
+
+// NewEdge returns the edge generator of type tp, args are the arguments of type,
+// like 10 and 2 of `decimal(10,2)`, members are used by enum and set
+func NewEdge(tp string, unsigned bool, args []int, members []string, mode SqlMode) *Edge {
+	return &Edge{edgeValues(tp, unsigned, args, members, mode)}
+}
+
+func (e *Edge) Gen() string {
+	return e.values[rand.Intn(len(e.values))]
+}
+
+func argOr(args []int, i int, defaul int) int {
+	if i < len(args) {
+		return args[i]
+	}
+	return defaul
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func withoutNegative(values []string, unsigned bool) []string {
+	if !unsigned {
+		return values
+	}
+	res := make([]string, 0, len(values))
+	for _, v := range values {
+		// -0 is 0 for unsigned types
+		if v == "-0" || !strings.HasPrefix(v, "-") {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// max, min, zeros and the smallest non-zero values of precision and scale
+func decimalEdges(precision int, scale int, unsigned bool) []string {
+	max := strings.Repeat("9", precision-scale)
+	if max == "" {
+		max = "0"
+	}
+	smallest := "1"
+	if scale > 0 {
+		max += "." + strings.Repeat("9", scale)
+		smallest = "0." + strings.Repeat("0", scale-1) + "1"
+	}
+	return withoutNegative([]string{max, "-" + max, "0", "-0", smallest, "-" + smallest}, unsigned)
+}
+
+func edgeValues(tp string, unsigned bool, args []int, members []string, mode SqlMode) []string {
+	if r, ok := intRanges[tp]; ok {
+		if unsigned {
+			return []string{"0", "-0", uintMax[tp], "1"}
+		}
+		return []string{r[0], r[1], "0", "-0", "-1", "1"}
+	}
+
+	switch tp {
+	case "bool", "boolean":
+		return []string{"0", "1"}
+	case "decimal", "numeric", "fixed":
+		return decimalEdges(argOr(args, 0, 10), argOr(args, 1, 0), unsigned)
+	case "float", "real", "double":
+		// the declared (M,D) limits the range like decimal
+		if len(args) == 2 {
+			return decimalEdges(args[0], args[1], unsigned)
+		}
+		if tp == "double" {
+			return withoutNegative(append([]string{"0", "-0"}, doubleEdges...), unsigned)
+		}
+		return withoutNegative(append([]string{"0", "-0"}, floatEdges...), unsigned)
+	case "bit":
+		return []string{"b'0'", "b'" + strings.Repeat("1", argOr(args, 0, 1)) + "'"}
+	case "json":
+		return []string{"'{}'", "'[]'", "'null'", "'0'", `'""'`}
+	case "enum":
+		values := make([]string, 0, 3)
+		// '' is rejected by strict modes unless it is a member
+		if mode.NonStrict || contains(members, "") || len(members) == 0 {
+			values = append(values, "''")
+		}
+		if len(members) > 0 {
+			values = append(values, quoteString(members[0]), quoteString(members[len(members)-1]))
+		}
+		return values
+	case "set":
+		return []string{"''", quoteString(strings.Join(members, ","))}
+	}
+
+	if values := temporalEdges(tp, argOr(args, 0, 0), mode.ZeroDates); values != nil {
+		return values
+	}
+
+	// strings
+	length := argOr(args, 0, textLength[tp])
+	if length <= 0 {
+		length = 1
+	}
+	return []string{"''", "' '", quoteString(strings.Repeat("z", length)),
+		quoteString(strings.Repeat(" ", length)), quoteString(strconv.Itoa(0))}
+}
@@ -0,0 +1,47 @@
+package generators
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestEdge(t *testing.T) {
+	assert.Equal(t, []string{"-128", "127", "0", "-0", "-1", "1"}, edgeValues("tinyint", false, nil, nil, SqlMode{}))
+	assert.Equal(t, []string{"0", "-0", "18446744073709551615", "1"}, edgeValues("bigint", true, nil, nil, SqlMode{}))
+	assert.Equal(t, []string{"999999.9999", "-999999.9999", "0", "-0", "0.0001", "-0.0001"},
+		edgeValues("decimal", false, []int{10, 4}, nil, SqlMode{}))
+	assert.Equal(t, []string{"0.99", "0", "-0", "0.01"}, edgeValues("decimal", true, []int{2, 2}, nil, SqlMode{}))
+	assert.Contains(t, edgeValues("date", false, nil, nil, SqlMode{}), "'2020-02-29'")
+	assert.Contains(t, edgeValues("time", false, nil, nil, SqlMode{}), "'-838:59:59'")
+	// '' of enum only if it is a member or the sql_mode is not strict
+	members := []string{"a", "b", "c"}
+	assert.Equal(t, []string{"'a'", "'c'"}, edgeValues("enum", false, nil, members, SqlMode{}))
+	assert.Equal(t, []string{"''", "'a'", "'c'"}, edgeValues("enum", false, nil, members, SqlMode{NonStrict: true}))
+	assert.Equal(t, []string{"''", "''", "'c'"}, edgeValues("enum", false, nil, []string{"", "c"}, SqlMode{}))
+	assert.Equal(t, []string{"''", "'a,b'"}, edgeValues("set", false, nil, []string{"a", "b"}, SqlMode{}))
+	// floats of declared precision and scale are in range
+	assert.Equal(t, []string{"999.99", "-999.99", "0", "-0", "0.01", "-0.01"},
+		edgeValues("float", false, []int{5, 2}, nil, SqlMode{}))
+	assert.Equal(t, []string{"9.9", "0", "-0", "0.1"}, edgeValues("double", true, []int{2, 1}, nil, SqlMode{}))
+	assert.Contains(t, edgeValues("double", false, nil, nil, SqlMode{}), "1.7976931348623157e308")
+	assert.Equal(t, []string{"b'0'", "b'111'"}, edgeValues("bit", false, []int{3}, nil, SqlMode{}))
+
+	// max temporals by fsp, zero dates only if they are valid
+	assert.Contains(t, edgeValues("datetime", false, nil, nil, SqlMode{}), "'9999-12-31 23:59:59'")
+	assert.Contains(t, edgeValues("datetime", false, []int{3}, nil, SqlMode{}), "'9999-12-31 23:59:59.999'")
+	assert.Contains(t, edgeValues("timestamp", false, []int{6}, nil, SqlMode{}), "'2038-01-19 03:14:07.999999'")
+	assert.NotContains(t, edgeValues("date", false, nil, nil, SqlMode{}), "'0000-00-00'")
+	assert.Contains(t, edgeValues("date", false, nil, nil, SqlMode{ZeroDates: true}), "'0000-00-00'")
+	assert.Contains(t, edgeValues("timestamp", false, nil, nil, SqlMode{ZeroDates: true}), "'0000-00-00 00:00:00'")
+
+	strs := edgeValues("varchar", false, []int{5}, nil, SqlMode{})
+	assert.Contains(t, strs, "''")
+	assert.Contains(t, strs, "'"+strings.Repeat("z", 5)+"'")
+
+	e := NewEdge("float", true, nil, nil, SqlMode{})
+	for i := 0; i < 20; i++ {
+		v := e.Gen()
+		assert.True(t, v == "-0" || !strings.HasPrefix(v, "-"), v)
+	}
+}
@@ -27,5 +27,5 @@ func newEnglish() *English {
 
 func (e *English) Gen() string {
 	// fix the `^M` character
-	return `"` + strings.ReplaceAll(e.dict[rand.Intn(len(e.dict))], "\r", "") + `"`
+	return quoteString(strings.ReplaceAll(e.dict[rand.Intn(len(e.dict))], "\r", ""))
 }
@@ -0,0 +1,20 @@
+package generators
+
+import (
+	"math"
+	"math/rand"
+	"strconv"
+)
+
+// random float in scientific notation or not, with magnitude
+// between 1e-5 and 1e10
+type Float struct {
+}
+
+func (*Float) Gen() string {
+	f := rand.Float64() * math.Pow10(randInRange(-5, 10))
+	if rand.Intn(2) == 0 {
+		f = -f
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,15 @@
+package generators
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"testing"
+)
+
+func TestFloat(t *testing.T) {
+	f := &Float{}
+	for i := 0; i < 100; i++ {
+		_, err := strconv.ParseFloat(f.Gen(), 64)
+		assert.Equal(t, nil, err)
+	}
+}
@@ -0,0 +1,34 @@
+package generators
+
+import (
+	"strings"
+)
+
+// Fsp appends fractional seconds to the time generated by gen,
+// like '2019-01-02 10:20:30' -> '2019-01-02 10:20:30.123'
+type Fsp struct {
+	gen Generator
+	fsp int
+	// probability of having more fractional digits than fsp
+	overflow float64
+}
+
+// NewFsp returns the generator with fsp fractional digits, at the ratio
+// overflow it generates 1 to 3 more digits (at most 9), which are rounded when inserted
+func NewFsp(gen Generator, fsp int, overflow float64) *Fsp {
+	return &Fsp{gen, fsp, overflow}
+}
+
+func (f *Fsp) Gen() string {
+	res := f.gen.Gen()
+	digits := min(f.fsp+overflowDigits(f.overflow), 9)
+	if digits == 0 {
+		return res
+	}
+
+	fraction := "." + randDigits(digits)
+	if strings.HasSuffix(res, "'") {
+		return res[:len(res)-1] + fraction + "'"
+	}
+	return res + fraction
+}
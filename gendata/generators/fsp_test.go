@@ -0,0 +1,23 @@
+package generators
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestFsp(t *testing.T) {
+	f := NewFsp(Get("datetime"), 6, 0)
+	for i := 0; i < 20; i++ {
+		assert.Regexp(t, `^'\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}\.\d{6}'$`, f.Gen())
+	}
+
+	f = NewFsp(Get("timestamp"), 0, 1)
+	for i := 0; i < 20; i++ {
+		assert.Regexp(t, `^\d{14}\.\d{1,3}$`, f.Gen())
+	}
+
+	f = NewFsp(Get("time"), 0, 0)
+	for i := 0; i < 20; i++ {
+		assert.Regexp(t, `^'\d{2}:\d{2}:\d{2}'$`, f.Gen())
+	}
+}
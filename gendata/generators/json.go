@@ -0,0 +1,195 @@
+package generators
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	jsonAny = iota
+	jsonObject
+	jsonArray
+	jsonScalar
+)
+
+const defaultJsonDepth = 3
+
+var defaultJsonKeys = []string{"a", "b", "c", "id", "name", "value", "中文", "key with space"}
+
+// JsonOptions is the max nested depth and the object keys of json documents,
+// documents and paths bound to the same options can match each other
+type JsonOptions struct {
+	Depth int
+	Keys  []string
+}
+
+var defaultJsonOptions = &JsonOptions{defaultJsonDepth, defaultJsonKeys}
+
+// NewJsonOptions returns json options, depth <= 0 or empty keys mean default
+func NewJsonOptions(depth int, keys []string) *JsonOptions {
+	if depth <= 0 {
+		depth = defaultJsonDepth
+	}
+	if len(keys) == 0 {
+		keys = defaultJsonKeys
+	}
+	return &JsonOptions{depth, keys}
+}
+
+// Bind returns a copy of a json document or path generator using o,
+// other generators are returned as they are
+func (o *JsonOptions) Bind(g Generator) Generator {
+	switch j := g.(type) {
+	case *Json:
+		return &Json{kind: j.kind, opts: o}
+	case *JsonPath:
+		return &JsonPath{wildcard: j.wildcard, opts: o}
+	}
+	return g
+}
+
+func (o *JsonOptions) orDefault() *JsonOptions {
+	if o == nil {
+		return defaultJsonOptions
+	}
+	return o
+}
+
+var jsonNumbers = []string{
+	"0", "-0", "1", "-1", "0.5", "-1.5e-10", "3.141592653589793",
+	"2147483647", "-2147483648",
+	"9223372036854775807", "-9223372036854775808",
+	"18446744073709551615", "18446744073709551616",
+	"1e308", "-1.7976931348623157e308", "5e-324",
+}
+
+var jsonStrings = []string{
+	"", " ", "abc", "中文", "é", "é", "😀", "Ω≈ç√",
+	`a"b`, `a\b`, "a'b", "line\nbreak", "tab\tx", "\u0000",
+}
+
+// random json document in a sql string literal, like '{"a": [1, "中文"], "b": null}'
+type Json struct {
+	kind int
+	// nil means default
+	opts *JsonOptions
+}
+
+func (j *Json) Gen() string {
+	buf := &bytes.Buffer{}
+	opts := j.opts.orDefault()
+	genJsonValue(buf, j.kind, opts.Depth, opts.Keys)
+	return quoteJsonLiteral(buf.String())
+}
+
+func genJsonValue(buf *bytes.Buffer, kind int, depth int, keys []string) {
+	if kind == jsonAny {
+		if depth <= 0 {
+			kind = jsonScalar
+		} else {
+			kind = []int{jsonObject, jsonArray, jsonScalar, jsonScalar}[rand.Intn(4)]
+		}
+	}
+
+	switch kind {
+	case jsonObject:
+		perm := rand.Perm(len(keys))[:randInRange(0, min(len(keys), 4))]
+		buf.WriteString("{")
+		for i, k := range perm {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			buf.WriteString(jsonString(keys[k]) + ": ")
+			genJsonValue(buf, jsonAny, depth-1, keys)
+		}
+		buf.WriteString("}")
+	case jsonArray:
+		buf.WriteString("[")
+		for i, n := 0, randInRange(0, 4); i < n; i++ {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			genJsonValue(buf, jsonAny, depth-1, keys)
+		}
+		buf.WriteString("]")
+	default:
+		switch rand.Intn(5) {
+		case 0:
+			buf.WriteString(jsonNumbers[rand.Intn(len(jsonNumbers))])
+		case 1:
+			buf.WriteString(strconv.Itoa(randInRange(-100, 100)))
+		case 2:
+			buf.WriteString(jsonString(jsonStrings[rand.Intn(len(jsonStrings))]))
+		case 3:
+			buf.WriteString([]string{"true", "false"}[rand.Intn(2)])
+		default:
+			buf.WriteString("null")
+		}
+	}
+}
+
+func jsonString(s string) string {
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+var sqlStringEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+
+func quoteJsonLiteral(doc string) string {
+	return "'" + sqlStringEscaper.Replace(doc) + "'"
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+var jsonIdentifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// random json path matching the generated documents in a sql string literal,
+// like '$.a[1]."key with space"', wildcard means it may contain `*` and `**`
+type JsonPath struct {
+	wildcard bool
+	// nil means default
+	opts *JsonOptions
+}
+
+func (j *JsonPath) Gen() string {
+	opts := j.opts.orDefault()
+	buf := &strings.Builder{}
+	buf.WriteString("$")
+	for i, n := 0, randInRange(0, opts.Depth); i < n; i++ {
+		leg := rand.Intn(5)
+		if j.wildcard && leg == 4 {
+			if i == 0 {
+				buf.WriteString("**")
+			} else {
+				buf.WriteString([]string{".*", "[*]"}[rand.Intn(2)])
+				continue
+			}
+			leg = 0
+		}
+
+		switch leg {
+		case 0, 1, 2:
+			key := opts.Keys[rand.Intn(len(opts.Keys))]
+			if jsonIdentifier.MatchString(key) {
+				buf.WriteString("." + key)
+			} else {
+				buf.WriteString("." + jsonString(key))
+			}
+		default:
+			buf.WriteString("[" + strconv.Itoa(randInRange(0, 3)) + "]")
+		}
+	}
+	return quoteJsonLiteral(buf.String())
+}
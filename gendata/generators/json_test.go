@@ -0,0 +1,84 @@
+package generators
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var jsonLeg = regexp.MustCompile(`\."key with space"|\[\d\]`)
+
+var sqlStringUnescaper = strings.NewReplacer(`\\`, `\`, `\'`, `'`)
+
+func unquoteJson(t *testing.T, literal string) interface{} {
+	assert.Equal(t, byte('\''), literal[0])
+	assert.Equal(t, byte('\''), literal[len(literal)-1])
+
+	var doc interface{}
+	err := json.Unmarshal([]byte(sqlStringUnescaper.Replace(literal[1:len(literal)-1])), &doc)
+	assert.Equal(t, nil, err, literal)
+	return doc
+}
+
+func jsonDepth(doc interface{}) int {
+	depth := 0
+	switch v := doc.(type) {
+	case map[string]interface{}:
+		for _, e := range v {
+			if d := jsonDepth(e) + 1; d > depth {
+				depth = d
+			}
+		}
+	case []interface{}:
+		for _, e := range v {
+			if d := jsonDepth(e) + 1; d > depth {
+				depth = d
+			}
+		}
+	}
+	return depth
+}
+
+func TestJson(t *testing.T) {
+	for i := 0; i < 200; i++ {
+		doc := unquoteJson(t, Get("json").Gen())
+		assert.True(t, jsonDepth(doc) <= defaultJsonDepth)
+
+		_, ok := unquoteJson(t, Get("json_object").Gen()).(map[string]interface{})
+		assert.True(t, ok)
+		_, ok = unquoteJson(t, Get("json_array").Gen()).([]interface{})
+		assert.True(t, ok)
+	}
+
+	object := NewJsonOptions(1, []string{"k"}).Bind(Get("json_object"))
+	for i := 0; i < 100; i++ {
+		doc, _ := unquoteJson(t, object.Gen()).(map[string]interface{})
+		for k, v := range doc {
+			assert.Equal(t, "k", k)
+			assert.Equal(t, 0, jsonDepth(v))
+		}
+	}
+}
+
+func TestJsonPath(t *testing.T) {
+	for i := 0; i < 200; i++ {
+		path := Get("json_path").Gen()
+		assert.True(t, strings.HasPrefix(path, "'$"), path)
+		assert.False(t, strings.Contains(path, "*"), path)
+		assert.True(t, strings.HasPrefix(Get("json_path_wildcard").Gen(), "'$"))
+	}
+
+	jsonPath := NewJsonOptions(2, []string{"key with space"}).Bind(Get("json_path"))
+	for i := 0; i < 100; i++ {
+		path := jsonPath.Gen()
+		legs := jsonLeg.FindAllString(path, -1)
+		assert.True(t, len(legs) <= 2, path)
+		assert.Equal(t, "'$'", jsonLeg.ReplaceAllString(path, ""))
+	}
+
+	// registered generators are not changed by binding
+	assert.Nil(t, Get("json_path").(*JsonPath).opts)
+	assert.Equal(t, Get("digit"), NewJsonOptions(0, nil).Bind(Get("digit")))
+}
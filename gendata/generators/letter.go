@@ -4,6 +4,6 @@ type Letter struct {
 }
 
 func (l *Letter) Gen() string {
-	return `'` + string(randInRange('a', 'z')) + `'`
+	return `'` + string(rune(randInRange('a', 'z'))) + `'`
 }
 
@@ -0,0 +1,277 @@
+package generators
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// argument of a generator expression, a number or a quoted string
+type Arg struct {
+	Val    string
+	Quoted bool
+}
+
+func (a Arg) int() (int, error) {
+	if a.Quoted {
+		return 0, fmt.Errorf("%s is not an integer", a.Val)
+	}
+	return strconv.Atoi(a.Val)
+}
+
+// sql literal of the argument
+func (a Arg) literal() string {
+	if a.Quoted {
+		return quoteString(a.Val)
+	}
+	return a.Val
+}
+
+func intArgs(args []Arg, min int, max int) ([]int, error) {
+	if len(args) < min || len(args) > max {
+		return nil, fmt.Errorf("expect %d to %d arguments, but got %d", min, max, len(args))
+	}
+	res := make([]int, len(args))
+	for i, arg := range args {
+		n, err := arg.int()
+		if err != nil {
+			return nil, err
+		}
+		res[i] = n
+	}
+	return res, nil
+}
+
+func timeArgs(args []Arg) (time.Time, time.Time, error) {
+	if len(args) != 2 {
+		return time.Time{}, time.Time{}, fmt.Errorf("expect 2 arguments, but got %d", len(args))
+	}
+	times := make([]time.Time, 2)
+	for i, arg := range args {
+		var err error
+		times[i], err = time.Parse("2006-01-02 15:04:05", arg.Val)
+		if err != nil {
+			if times[i], err = time.Parse("2006-01-02", arg.Val); err != nil {
+				return time.Time{}, time.Time{}, err
+			}
+		}
+	}
+	if times[1].Before(times[0]) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%s is before %s", args[1].Val, args[0].Val)
+	}
+	return times[0], times[1], nil
+}
+
+// constructors of built-in parameterized generators, see Register
+var builtinParams = map[string]func(args []Arg) (Generator, error){
+	"int": func(args []Arg) (Generator, error) {
+		ns, err := intArgs(args, 2, 2)
+		if err != nil {
+			return nil, err
+		}
+		if ns[1] < ns[0] {
+			return nil, fmt.Errorf("max %d is less than min %d", ns[1], ns[0])
+		}
+		return newInt(ns[0], ns[1], ""), nil
+	},
+	"char": func(args []Arg) (Generator, error) {
+		ns, err := intArgs(args, 1, 2)
+		if err != nil {
+			return nil, err
+		}
+		if len(ns) == 1 {
+			return NewChar(ns[0]), nil
+		}
+		return NewCharRange(ns[0], ns[1]), nil
+	},
+	"decimal": func(args []Arg) (Generator, error) {
+		ns, err := intArgs(args, 1, 2)
+		if err != nil {
+			return nil, err
+		}
+		if len(ns) == 1 {
+			ns = append(ns, 0)
+		}
+		if ns[0] <= 0 || ns[1] < 0 || ns[1] > ns[0] {
+			return nil, fmt.Errorf("illegal precision %d and scale %d", ns[0], ns[1])
+		}
+		return NewDecimal(ns[0], ns[1]), nil
+	},
+	"date": func(args []Arg) (Generator, error) {
+		from, to, err := timeArgs(args)
+		if err != nil {
+			return nil, err
+		}
+		return NewTimeRange(from, to, "2006-01-02"), nil
+	},
+	"datetime": func(args []Arg) (Generator, error) {
+		from, to, err := timeArgs(args)
+		if err != nil {
+			return nil, err
+		}
+		return NewTimeRange(from, to, "2006-01-02 15:04:05"), nil
+	},
+	"choice": func(args []Arg) (Generator, error) {
+		if len(args) == 0 {
+			return nil, fmt.Errorf("choice needs at least one argument")
+		}
+		literals := make([]string, len(args))
+		for i, arg := range args {
+			literals[i] = arg.literal()
+		}
+		return &Choice{literals}, nil
+	},
+	"bit": func(args []Arg) (Generator, error) {
+		ns, err := intArgs(args, 1, 1)
+		if err != nil {
+			return nil, err
+		}
+		return NewBit(ns[0]), nil
+	},
+	"binary": func(args []Arg) (Generator, error) {
+		ns, err := intArgs(args, 1, 1)
+		if err != nil {
+			return nil, err
+		}
+		return NewBinary(ns[0], true), nil
+	},
+	"varbinary": func(args []Arg) (Generator, error) {
+		ns, err := intArgs(args, 1, 1)
+		if err != nil {
+			return nil, err
+		}
+		return NewBinary(ns[0], false), nil
+	},
+}
+
+// Parse parses a generator expression, which is the name of a registered
+// or stateful generator, a parameterized generator like `int(-5,5)` and `choice('a','b')`,
+// or `unique(expr)`. Every call returns a new generator if it is Stateful
+func Parse(expr string) (Generator, error) {
+	expr = strings.TrimSpace(expr)
+	index := strings.Index(expr, "(")
+	if index == -1 {
+		if g := Get(expr); g != nil {
+			return g, nil
+		}
+		if g := NewStateful(expr); g != nil {
+			return g, nil
+		}
+		return nil, fmt.Errorf("generator %s not found", expr)
+	}
+
+	name := strings.TrimSpace(expr[:index])
+	if name == "unique" && strings.HasSuffix(expr, ")") {
+		g, err := Parse(expr[index+1 : len(expr)-1])
+		if err != nil {
+			return nil, err
+		}
+		return NewUnique(g), nil
+	}
+
+	newGen, ok := paramGens[name]
+	if !ok {
+		return nil, fmt.Errorf("generator %s does not support arguments", name)
+	}
+	if !strings.HasSuffix(expr, ")") {
+		return nil, fmt.Errorf("illegal generator expression %s", expr)
+	}
+
+	args, err := ParseArgs(expr[index+1 : len(expr)-1])
+	if err != nil {
+		return nil, fmt.Errorf("illegal generator expression %s, %v", expr, err)
+	}
+	if err := checkArgs(infos[name].Params, args); err != nil {
+		return nil, fmt.Errorf("illegal generator expression %s, %v", expr, err)
+	}
+
+	g, err := newGen(args)
+	if err != nil {
+		return nil, fmt.Errorf("illegal generator expression %s, %v", expr, err)
+	}
+	return g, nil
+}
+
+// ParseArgs parses comma separated numbers and quoted strings
+func ParseArgs(s string) ([]Arg, error) {
+	args := make([]Arg, 0)
+	if strings.TrimSpace(s) == "" {
+		return args, nil
+	}
+
+	i := 0
+	for {
+		for i < len(s) && s[i] == ' ' {
+			i++
+		}
+		if i >= len(s) {
+			return nil, fmt.Errorf("missing argument")
+		}
+
+		if s[i] == '\'' || s[i] == '"' {
+			quote := s[i]
+			buf := &strings.Builder{}
+			i++
+			for ; i < len(s) && s[i] != quote; i++ {
+				if s[i] == '\\' && i+1 < len(s) {
+					i++
+				}
+				buf.WriteByte(s[i])
+			}
+			if i >= len(s) {
+				return nil, fmt.Errorf("unclosed string")
+			}
+			i++
+			args = append(args, Arg{buf.String(), true})
+		} else {
+			start := i
+			for i < len(s) && s[i] != ',' && s[i] != ' ' {
+				i++
+			}
+			num := s[start:i]
+			if _, err := strconv.ParseFloat(num, 64); err != nil {
+				return nil, fmt.Errorf("%s is not a number", num)
+			}
+			args = append(args, Arg{num, false})
+		}
+
+		for i < len(s) && s[i] == ' ' {
+			i++
+		}
+		if i == len(s) {
+			return args, nil
+		}
+		if s[i] != ',' {
+			return nil, fmt.Errorf("expect ',' at %d", i)
+		}
+		i++
+	}
+}
+
+// random one of literals
+type Choice struct {
+	literals []string
+}
+
+func (c *Choice) Gen() string {
+	return c.literals[rand.Intn(len(c.literals))]
+}
+
+// random time in [from, to] formatted by layout
+type TimeRange struct {
+	from   time.Time
+	to     time.Time
+	layout string
+}
+
+func NewTimeRange(from time.Time, to time.Time, layout string) *TimeRange {
+	return &TimeRange{from, to, layout}
+}
+
+func (t *TimeRange) Gen() string {
+	seconds := int64(t.to.Sub(t.from) / time.Second)
+	cur := t.from.Add(time.Duration(rand.Int63n(seconds+1)) * time.Second)
+	return `'` + cur.Format(t.layout) + `'`
+}
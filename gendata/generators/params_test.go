@@ -0,0 +1,69 @@
+package generators
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	g, err := Parse("int(-5, 5)")
+	assert.Equal(t, nil, err)
+	for i := 0; i < 50; i++ {
+		n, err := strconv.Atoi(g.Gen())
+		assert.Equal(t, nil, err)
+		assert.True(t, n >= -5 && n <= 5, n)
+	}
+
+	g, err = Parse("char(3,5)")
+	assert.Equal(t, nil, err)
+	for i := 0; i < 50; i++ {
+		s := strings.Trim(g.Gen(), "'")
+		assert.True(t, len(s) >= 3 && len(s) <= 5, s)
+	}
+
+	g, err = Parse("decimal(6,2)")
+	assert.Equal(t, nil, err)
+	for i := 0; i < 50; i++ {
+		assert.Regexp(t, `^-?\d{1,4}\.\d{2}$`, g.Gen())
+	}
+
+	g, err = Parse("datetime('2020-01-01','2020-01-02')")
+	assert.Equal(t, nil, err)
+	for i := 0; i < 50; i++ {
+		v := g.Gen()
+		assert.True(t, v >= "'2020-01-01 00:00:00'" && v <= "'2020-01-02 00:00:00'", v)
+	}
+
+	g, err = Parse(`choice('a', "it's", 3)`)
+	assert.Equal(t, nil, err)
+	for i := 0; i < 50; i++ {
+		assert.Contains(t, []string{"'a'", `'it\'s'`, "3"}, g.Gen())
+	}
+
+	g, err = Parse("letter")
+	assert.Equal(t, nil, err)
+	assert.NotEqual(t, nil, g)
+
+	for _, expr := range []string{"int(5,-5)", "int(1)", "letter(1)", "nothing", "char('a')",
+		"decimal(2,3)", "choice()", "int(1,2", "datetime('2020-01-01')"} {
+		_, err = Parse(expr)
+		assert.NotEqual(t, nil, err, expr)
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	args, err := ParseArgs(` 1, -2.5 ,'a,b', "c\"d" `)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []Arg{{"1", false}, {"-2.5", false}, {"a,b", true}, {`c"d`, true}}, args)
+
+	args, err = ParseArgs("")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, len(args))
+
+	for _, s := range []string{"1,", "'a", "a", "1 2"} {
+		_, err = ParseArgs(s)
+		assert.NotEqual(t, nil, err, s)
+	}
+}
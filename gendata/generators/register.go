@@ -1,12 +1,110 @@
 package generators
 
-// the implementation of it should not have status
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// the implementation of it should not have status,
+// generators with status implement Stateful
 type Generator interface {
 	Gen() string
 }
 
+// Param is the schema of an argument of parameterized generators
+type Param struct {
+	Name string
+	// "number", "string", or "" which means both
+	Kind string
+	// only the last arguments can be optional or repeated
+	Optional bool
+	Repeated bool
+}
+
+// Meta is the optional metadata of a generator
+type Meta struct {
+	Desc string
+	// schema of arguments of the parameterized form, like min and max of `int(1,10)`
+	Params []Param
+	// constructor of the parameterized form, args have been checked by Params
+	New func(args []Arg) (Generator, error)
+	// arguments of a sample of the parameterized form, like `1,10`
+	Example string
+}
+
+// Info is a registered generator
+type Info struct {
+	Name string
+	Meta
+	// it can be used without arguments
+	Plain    bool
+	Stateful bool
+}
+
+// usage of generator, like `int`, `int(min, max)` and `choice(value, ...)`
+func (i *Info) Usage() string {
+	usages := make([]string, 0, 2)
+	if i.Plain {
+		usages = append(usages, i.Name)
+	}
+	if i.New != nil {
+		params := make([]string, len(i.Params))
+		for j, p := range i.Params {
+			params[j] = p.Name
+			if p.Optional {
+				params[j] = "[" + p.Name + "]"
+			}
+			if p.Repeated {
+				params[j] += ", ..."
+			}
+		}
+		usages = append(usages, i.Name+"("+strings.Join(params, ", ")+")")
+	}
+	return strings.Join(usages, ", ")
+}
+
+// Samples generates n sample values of the generator
+func (i *Info) Samples(n int) []string {
+	var g Generator
+	var err error
+	if i.Plain {
+		g, err = Parse(i.Name)
+	} else {
+		g, err = Parse(i.Name + "(" + i.Example + ")")
+	}
+	if err != nil {
+		return nil
+	}
+	samples := make([]string, n)
+	for j := range samples {
+		samples[j] = g.Gen()
+	}
+	return samples
+}
+
 var gmap = make(map[string]Generator)
 
+var infos = make(map[string]*Info)
+
+// constructors of parameterized generators
+var paramGens = make(map[string]func(args []Arg) (Generator, error))
+
+// constructors of stateful generators
+var statefulMap = make(map[string]func() Stateful)
+
+// names served before the registered generators, like the field
+// bound generators of zz data, they can not be registered
+var reserved = make(map[string]bool)
+
+// Reserve prevents names from being registered
+func Reserve(names ...string) {
+	for _, name := range names {
+		reserved[name] = true
+	}
+}
+
 func Get(name string) Generator {
 	g, ok := gmap[name]
 	if !ok {
@@ -21,50 +119,273 @@ func Traverse(h func(string, Generator)) {
 	}
 }
 
+// Infos returns all registered generators sorted by name
+func Infos() []*Info {
+	res := make([]*Info, 0, len(infos))
+	for _, info := range infos {
+		res = append(res, info)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+	return res
+}
+
+func newInfo(name string, meta []Meta) (*Info, error) {
+	if name == "" || strings.ContainsAny(name, "(), '\"") {
+		return nil, fmt.Errorf("illegal generator name '%s'", name)
+	}
+	if _, ok := infos[name]; ok {
+		return nil, fmt.Errorf("generator %s has been registered", name)
+	}
+	if reserved[name] {
+		return nil, fmt.Errorf("generator name %s is reserved", name)
+	}
+	if len(meta) > 1 {
+		return nil, fmt.Errorf("generator %s has more than one meta", name)
+	}
+
+	info := &Info{Name: name}
+	if len(meta) == 1 {
+		info.Meta = meta[0]
+	}
+	if len(info.Params) > 0 && info.New == nil {
+		return nil, fmt.Errorf("generator %s has params but no constructor", name)
+	}
+	if info.New != nil && len(info.Params) == 0 {
+		return nil, fmt.Errorf("generator %s has a constructor but no params", name)
+	}
+	return info, nil
+}
+
+// Register registers a generator by name, which can be used in zz data
+// and as yy keyword `_<name>`. If meta has a constructor, it can also be
+// used with arguments, like `int(1,10)`, g can be nil if it can only be
+// used with arguments. g should not have status, see RegisterStateful
+func Register(name string, g Generator, meta ...Meta) error {
+	info, err := newInfo(name, meta)
+	if err != nil {
+		return err
+	}
+	if g == nil && info.New == nil {
+		return fmt.Errorf("generator %s is nil", name)
+	}
+
+	info.Plain = g != nil
+	infos[name] = info
+	if g != nil {
+		gmap[name] = g
+	}
+	if info.New != nil {
+		paramGens[name] = info.New
+	}
+	return nil
+}
+
+// RegisterStateful registers a stateful generator by name, every
+// table and column gets its own one by newGen
+func RegisterStateful(name string, newGen func() Stateful, meta ...Meta) error {
+	info, err := newInfo(name, meta)
+	if err != nil {
+		return err
+	}
+	if info.New != nil {
+		return fmt.Errorf("stateful generator %s can not have arguments", name)
+	}
+
+	info.Plain = true
+	info.Stateful = true
+	infos[name] = info
+	statefulMap[name] = newGen
+	return nil
+}
+
+func mustRegister(name string, g Generator, meta ...Meta) {
+	if err := Register(name, g, meta...); err != nil {
+		panic(err)
+	}
+}
+
+// check args by the schema of params
+func checkArgs(params []Param, args []Arg) error {
+	required := 0
+	for _, p := range params {
+		if !p.Optional {
+			required++
+		}
+	}
+	repeated := len(params) > 0 && params[len(params)-1].Repeated
+	if len(args) < required || (!repeated && len(args) > len(params)) {
+		return fmt.Errorf("expect %d to %d arguments, but got %d", required, len(params), len(args))
+	}
+
+	for i, arg := range args {
+		p := params[min(i, len(params)-1)]
+		if p.Kind == "number" && arg.Quoted {
+			return fmt.Errorf("%s must be a number", p.Name)
+		}
+		if p.Kind == "string" && !arg.Quoted {
+			return fmt.Errorf("%s must be a quoted string", p.Name)
+		}
+	}
+	return nil
+}
+
 func init() {
-	gmap["digit"] = &Digit{}
-	gmap["letter"] = &Letter{}
 	/*  temporal
-    yyyy-MM-dd HH:mm:ss.SSS
-    */
-	gmap["date"] = newTemporal(yyyy, dd)
-	gmap["year"] = newTemporal(yyyy, yyyy)
-	gmap["month"] = newTemporal(MM, MM)
-	gmap["day"] = newTemporal(dd, dd)
-	gmap["hour"] = newTemporal(HH, HH)
-	gmap["minute"] = newTemporal(mm, mm)
-	gmap["second"] = newTemporal(ss, ss)
-	gmap["microsecond"] = newTemporal(SSS, SSS)
-	gmap["time"] = newTemporal(HH, ss)
-	gmap["datetime"] = newTemporal(yyyy, ss)
-	gmap["second_microsecond"] = newTemporal(ss, SSS)
-	gmap["minute_microsecond"] = newTemporal(mm, SSS)
-	gmap["minute_second"] = newTemporal(mm, ss)
-	gmap["hour_microsecond"] = newTemporal(HH, SSS)
-	gmap["hour_second"] = newTemporal(HH, ss)
-	gmap["hour_minute"] = newTemporal(HH, mm)
-	gmap["day_microsecond"] = newTemporal(dd, SSS)
-	gmap["day_second"] = newTemporal(dd, ss)
-	gmap["day_minute"] = newTemporal(dd, mm)
-	gmap["day_hour"] = newTemporal(dd, HH)
-	gmap["year_month"] = newTemporal(yyyy, MM)
-
-	gmap["timestamp"] = &Timestamp{}
-	gmap["english"] = newEnglish()
-	gmap["char"] = NewChar(10)
-	gmap["bool"] = newInt(0, 1, "")
-	gmap["boolean"] = newInt(0, 1, "")
-	gmap["tinyint"] = newInt(-128, 127, "")
-	gmap["tinyint_unsigned"] = newInt(0, 255, "")
-	gmap["smallint"] = newInt(-32768, 32767, "")
-	gmap["smallint_unsigned"] = newInt(0, 65535, "")
-	gmap["mediumint"] = newInt(-8388608, 8388607, "")
-	gmap["mediumint_unsigned"] = newInt(0, 16777215, "")
-	gmap["bigint"] = newBigInt(false)
-	gmap["bigint_unsigned"] = newBigInt(true)
-	gmap["int"] = newInt(0, -1, "")
-	gmap["int_usigned"] = &Uint{}
-	gmap["integer"] = newInt(0, -1, "")
-	gmap["decimal"] = &Decimal{}
+	    yyyy-MM-dd HH:mm:ss.SSS
+	*/
+	temporals := []struct {
+		name string
+		from int
+		to   int
+	}{
+		{"year", yyyy, yyyy},
+		{"month", MM, MM},
+		{"day", dd, dd},
+		{"hour", HH, HH},
+		{"minute", mm, mm},
+		{"second", ss, ss},
+		{"microsecond", SSS, SSS},
+		{"time", HH, ss},
+		{"second_microsecond", ss, SSS},
+		{"minute_microsecond", mm, SSS},
+		{"minute_second", mm, ss},
+		{"hour_microsecond", HH, SSS},
+		{"hour_second", HH, ss},
+		{"hour_minute", HH, mm},
+		{"day_microsecond", dd, SSS},
+		{"day_second", dd, ss},
+		{"day_minute", dd, mm},
+		{"day_hour", dd, HH},
+		{"year_month", yyyy, MM},
+	}
+	for _, t := range temporals {
+		mustRegister(t.name, newTemporal(t.from, t.to), Meta{Desc: "random quoted " +
+			strings.Replace(t.name, "_", " to ", 1)})
+	}
+	timeRange := []Param{{Name: "from", Kind: "string"}, {Name: "to", Kind: "string"}}
+	mustRegister("date", newTemporal(yyyy, dd), Meta{
+		Desc:    "random quoted yyyy-MM-dd date, or in [from, to]",
+		Params:  timeRange,
+		New:     builtinParams["date"],
+		Example: "'2020-01-01','2020-12-31'",
+	})
+	mustRegister("datetime", newTemporal(yyyy, ss), Meta{
+		Desc:    "random quoted yyyy-MM-dd HH:mm:ss datetime, or in [from, to]",
+		Params:  timeRange,
+		New:     builtinParams["datetime"],
+		Example: "'2020-01-01','2020-12-31'",
+	})
+	mustRegister("timestamp", &Timestamp{}, Meta{Desc: "random yyyymmddhhmmss number"})
 
+	mustRegister("digit", &Digit{}, Meta{Desc: "random number in 0-9"})
+	mustRegister("letter", &Letter{}, Meta{Desc: "random quoted letter from a to z"})
+	mustRegister("english", newEnglish(), Meta{Desc: "random quoted English word"})
+	mustRegister("char", NewChar(10), Meta{
+		Desc:    "random quoted letters, 10 letters, length letters or min to max letters",
+		Params:  []Param{{Name: "length", Kind: "number"}, {Name: "max", Kind: "number", Optional: true}},
+		New:     builtinParams["char"],
+		Example: "3,5",
+	})
+	mustRegister("bool", newInt(0, 1, ""), Meta{Desc: "0 or 1"})
+	mustRegister("boolean", newInt(0, 1, ""), Meta{Desc: "0 or 1"})
+	mustRegister("tinyint", newInt(-128, 127, ""), Meta{Desc: "random tinyint"})
+	mustRegister("tinyint_unsigned", newInt(0, 255, ""), Meta{Desc: "random unsigned tinyint"})
+	mustRegister("smallint", newInt(-32768, 32767, ""), Meta{Desc: "random smallint"})
+	mustRegister("smallint_unsigned", newInt(0, 65535, ""), Meta{Desc: "random unsigned smallint"})
+	mustRegister("mediumint", newInt(-8388608, 8388607, ""), Meta{Desc: "random mediumint"})
+	mustRegister("mediumint_unsigned", newInt(0, 16777215, ""), Meta{Desc: "random unsigned mediumint"})
+	mustRegister("bigint", newBigInt(false), Meta{Desc: "random bigint"})
+	mustRegister("bigint_unsigned", newBigInt(true), Meta{Desc: "random unsigned bigint"})
+	mustRegister("int", newInt(0, -1, ""), Meta{
+		Desc:    "random int, or in [min, max]",
+		Params:  []Param{{Name: "min", Kind: "number"}, {Name: "max", Kind: "number"}},
+		New:     builtinParams["int"],
+		Example: "-5,5",
+	})
+	mustRegister("int_unsigned", &Uint{}, Meta{Desc: "random unsigned int"})
+	// misspelled name in old versions
+	mustRegister("int_usigned", &Uint{}, Meta{Desc: "deprecated, use int_unsigned"})
+	mustRegister("integer", newInt(0, -1, ""), Meta{Desc: "random int"})
+	mustRegister("decimal", &Decimal{}, Meta{
+		Desc:    "random decimal like 12.0123, or with precision and scale",
+		Params:  []Param{{Name: "precision", Kind: "number"}, {Name: "scale", Kind: "number", Optional: true}},
+		New:     builtinParams["decimal"],
+		Example: "12,4",
+	})
+	mustRegister("float", &Float{}, Meta{Desc: "random float between 1e-5 and 1e10"})
+	mustRegister("double", &Float{}, Meta{Desc: "random double between 1e-5 and 1e10"})
+	mustRegister("choice", nil, Meta{
+		Desc:    "one of the numbers and quoted strings",
+		Params:  []Param{{Name: "value", Repeated: true}},
+		New:     builtinParams["choice"],
+		Example: "'a','b',1",
+	})
+
+	length := []Param{{Name: "length", Kind: "number"}}
+	mustRegister("bit", NewBit(1), Meta{
+		Desc:    "random bit literal with at most length bits",
+		Params:  length,
+		New:     builtinParams["bit"],
+		Example: "8",
+	})
+	mustRegister("binary", NewBinary(1, true), Meta{
+		Desc:    "random hex literal of length bytes",
+		Params:  length,
+		New:     builtinParams["binary"],
+		Example: "4",
+	})
+	mustRegister("varbinary", NewBinary(16, false), Meta{
+		Desc:    "random hex literal of at most length bytes",
+		Params:  length,
+		New:     builtinParams["varbinary"],
+		Example: "4",
+	})
+	for name, newGen := range textGens {
+		newGen := newGen
+		mustRegister(name, newGen(10), Meta{
+			Desc:   textDescs[name],
+			Params: length,
+			New: func(args []Arg) (Generator, error) {
+				ns, err := intArgs(args, 1, 1)
+				if err != nil {
+					return nil, err
+				}
+				return newGen(ns[0]), nil
+			},
+			Example: "5",
+		})
+	}
+
+	mustRegister("json", &Json{kind: jsonAny}, Meta{Desc: "random quoted json document"})
+	mustRegister("json_object", &Json{kind: jsonObject}, Meta{Desc: "random quoted json object"})
+	mustRegister("json_array", &Json{kind: jsonArray}, Meta{Desc: "random quoted json array"})
+	mustRegister("json_scalar", &Json{kind: jsonScalar}, Meta{Desc: "random quoted json scalar"})
+	mustRegister("json_path", &JsonPath{wildcard: false}, Meta{Desc: "random quoted json path"})
+	mustRegister("json_path_wildcard", &JsonPath{wildcard: true}, Meta{Desc: "random quoted json path with * and **"})
+
+	mustRegisterStateful("seq", func() Stateful {
+		return &Seq{}
+	}, Meta{Desc: "1, 2, 3... for every table and column"})
+	mustRegisterStateful("monotonic_datetime", func() Stateful {
+		return NewMonotonic(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), time.Hour)
+	}, Meta{Desc: "increasing quoted datetime from 2000-01-01 for every table and column"})
+	// unique is parsed by Parse
+	infos["unique"] = &Info{
+		Name: "unique",
+		Meta: Meta{
+			Desc:    "values of gen which are never the same for every table and column (except NULL)",
+			Params:  []Param{{Name: "gen"}},
+			New:     func(args []Arg) (Generator, error) { return nil, fmt.Errorf("unique needs a generator") },
+			Example: "int(1,10)",
+		},
+		Stateful: true,
+	}
+}
+
+func mustRegisterStateful(name string, newGen func() Stateful, meta ...Meta) {
+	if err := RegisterStateful(name, newGen, meta...); err != nil {
+		panic(err)
+	}
 }
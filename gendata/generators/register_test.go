@@ -0,0 +1,97 @@
+package generators
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+type constGen struct {
+	val string
+}
+
+func (c *constGen) Gen() string {
+	return c.val
+}
+
+func TestRegister(t *testing.T) {
+	err := Register("test_const", &constGen{"'c'"}, Meta{
+		Desc:   "constant or repeated constant",
+		Params: []Param{{Name: "times", Kind: "number"}},
+		New: func(args []Arg) (Generator, error) {
+			ns, err := intArgs(args, 1, 1)
+			if err != nil {
+				return nil, err
+			}
+			return &constGen{"'" + strings.Repeat("c", ns[0]) + "'"}, nil
+		},
+		Example: "2",
+	})
+	assert.Equal(t, nil, err)
+	defer func() {
+		delete(infos, "test_const")
+		delete(gmap, "test_const")
+		delete(paramGens, "test_const")
+	}()
+
+	assert.Equal(t, "'c'", Get("test_const").Gen())
+	g, err := Parse("test_const(3)")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "'ccc'", g.Gen())
+
+	_, err = Parse("test_const('3')")
+	assert.Equal(t, "illegal generator expression test_const('3'), times must be a number", err.Error())
+	_, err = Parse("test_const(1,2)")
+	assert.Equal(t, "illegal generator expression test_const(1,2), expect 1 to 1 arguments, but got 2",
+		err.Error())
+
+	err = Register("test_const", &constGen{"1"})
+	assert.Equal(t, "generator test_const has been registered", err.Error())
+	err = Register("int", &constGen{"1"})
+	assert.Equal(t, "generator int has been registered", err.Error())
+	err = Register("seq", &constGen{"1"})
+	assert.Equal(t, "generator seq has been registered", err.Error())
+	err = Register("a(b)", &constGen{"1"})
+	assert.Equal(t, "illegal generator name 'a(b)'", err.Error())
+	err = Register("test_nil", nil)
+	assert.Equal(t, "generator test_nil is nil", err.Error())
+	err = Register("test_no_params", nil, Meta{New: func(args []Arg) (Generator, error) {
+		return &constGen{"1"}, nil
+	}})
+	assert.Equal(t, "generator test_no_params has a constructor but no params", err.Error())
+
+	Reserve("test_reserved")
+	defer delete(reserved, "test_reserved")
+	err = Register("test_reserved", &constGen{"1"})
+	assert.Equal(t, "generator name test_reserved is reserved", err.Error())
+	err = RegisterStateful("test_reserved", func() Stateful { return &Seq{} })
+	assert.Equal(t, "generator name test_reserved is reserved", err.Error())
+
+	var info *Info
+	for _, i := range Infos() {
+		if i.Name == "test_const" {
+			info = i
+		}
+	}
+	assert.Equal(t, "test_const, test_const(times)", info.Usage())
+	assert.Equal(t, []string{"'c'", "'c'"}, info.Samples(2))
+}
+
+func TestInfos(t *testing.T) {
+	all := Infos()
+	names := make(map[string]*Info)
+	for i, info := range all {
+		if i > 0 {
+			assert.True(t, all[i-1].Name < info.Name)
+		}
+		assert.NotEqual(t, "", info.Desc, info.Name)
+		assert.Equal(t, 3, len(info.Samples(3)), info.Name)
+		names[info.Name] = info
+	}
+
+	assert.NotEqual(t, nil, Get("int_unsigned"))
+	assert.Equal(t, "choice(value, ...)", names["choice"].Usage())
+	assert.Equal(t, "char, char(length, [max])", names["char"].Usage())
+	assert.Equal(t, []string{"1", "2"}, names["seq"].Samples(2))
+	assert.True(t, names["unique"].Stateful)
+}
@@ -0,0 +1,140 @@
+package generators
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Stateful is a generator with status, like sequences. Unlike the generators
+// returned by Get, every table and column has its own one, and it is reset
+// before generating the data of them
+type Stateful interface {
+	Generator
+	Reset()
+}
+
+// NewStateful returns a new stateful generator of name, nil if it is not found
+func NewStateful(name string) Stateful {
+	newGen, ok := statefulMap[name]
+	if !ok {
+		return nil
+	}
+	return newGen()
+}
+
+// 1, 2, 3...
+type Seq struct {
+	cur int
+}
+
+func (s *Seq) Gen() string {
+	s.cur++
+	return strconv.Itoa(s.cur)
+}
+
+func (s *Seq) Reset() {
+	s.cur = 0
+}
+
+// increasing datetime from start, the step between two values is
+// random in [1s, maxStep]
+type Monotonic struct {
+	start   time.Time
+	maxStep time.Duration
+	cur     time.Time
+}
+
+func NewMonotonic(start time.Time, maxStep time.Duration) *Monotonic {
+	return &Monotonic{start, maxStep, start}
+}
+
+func (m *Monotonic) Gen() string {
+	step := randInRange(1, int(m.maxStep/time.Second))
+	m.cur = m.cur.Add(time.Duration(step) * time.Second)
+	return `'` + m.cur.Format("2006-01-02 15:04:05") + `'`
+}
+
+func (m *Monotonic) Reset() {
+	m.cur = m.start
+}
+
+// max times of regenerating a value which has been generated
+const uniqueRetry = 100
+
+// Unique never generates the same value twice (except NULL), it retries gen, and
+// makes a quoted string unique by appending the number of values if retries fail.
+// It stops with an error if no more unique value can be generated
+type Unique struct {
+	gen  Generator
+	seen map[string]bool
+	// max characters of strings, 0 means unlimited
+	maxLength int
+}
+
+func NewUnique(gen Generator) *Unique {
+	return &Unique{gen: gen, seen: make(map[string]bool)}
+}
+
+// SetMaxLength limits the characters of strings with suffixes,
+// like the declared length of a varchar column
+func (u *Unique) SetMaxLength(n int) {
+	u.maxLength = n
+}
+
+func (u *Unique) Gen() string {
+	var v string
+	for i := 0; i < uniqueRetry; i++ {
+		v = u.gen.Gen()
+		if strings.EqualFold(v, "null") {
+			return v
+		}
+		if !u.seen[v] {
+			u.seen[v] = true
+			return v
+		}
+	}
+
+	// a number with a suffix is out of range in most cases
+	if len(v) < 2 || v[0] != '\'' || v[len(v)-1] != '\'' {
+		log.Fatalf("unique generator can not generate a new value after %d values\n", len(u.seen))
+	}
+	for n := len(u.seen); ; n++ {
+		suffixed, ok := u.withSuffix(v[1:len(v)-1], "_"+strconv.Itoa(n))
+		if !ok {
+			log.Fatalf("unique generator can not generate a new value of at most %d characters "+
+				"after %d values\n", u.maxLength, len(u.seen))
+		}
+		if !u.seen[suffixed] {
+			u.seen[suffixed] = true
+			return suffixed
+		}
+	}
+}
+
+// quoted content with suffix, the content is truncated to keep the max length
+func (u *Unique) withSuffix(content string, suffix string) (string, bool) {
+	if u.maxLength > 0 {
+		keep := u.maxLength - len(suffix)
+		if keep < 0 {
+			return "", false
+		}
+		runes := []rune(content)
+		if len(runes) > keep {
+			content = string(runes[:keep])
+			// do not break an escape sequence
+			if trailing := len(content) - len(strings.TrimRight(content, `\`)); trailing%2 == 1 {
+				content = content[:len(content)-1]
+			}
+		}
+	}
+	return "'" + content + suffix + "'", true
+}
+
+func (u *Unique) Reset() {
+	u.seen = make(map[string]bool)
+	if s, ok := u.gen.(Stateful); ok {
+		s.Reset()
+	}
+}
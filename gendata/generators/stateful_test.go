@@ -0,0 +1,70 @@
+package generators
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSeq(t *testing.T) {
+	s := NewStateful("seq")
+	assert.Equal(t, "1", s.Gen())
+	assert.Equal(t, "2", s.Gen())
+	s.Reset()
+	assert.Equal(t, "1", s.Gen())
+	assert.Equal(t, nil, NewStateful("letter"))
+	assert.Equal(t, nil, Get("seq"))
+}
+
+func TestMonotonic(t *testing.T) {
+	m := NewStateful("monotonic_datetime")
+	first := m.Gen()
+	prev := first
+	for i := 0; i < 50; i++ {
+		cur := m.Gen()
+		assert.True(t, cur > prev, cur)
+		prev = cur
+	}
+	m.Reset()
+	assert.True(t, m.Gen() <= prev)
+}
+
+func TestUnique(t *testing.T) {
+	g, err := Parse("unique(int(1,3))")
+	assert.Equal(t, nil, err)
+	seen := make(map[string]bool)
+	for i := 0; i < 3; i++ {
+		v := g.Gen()
+		assert.False(t, seen[v], v)
+		seen[v] = true
+	}
+
+	u := NewUnique(&Choice{[]string{"'a'"}})
+	assert.Equal(t, "'a'", u.Gen())
+	assert.Equal(t, "'a_1'", u.Gen())
+	u.Reset()
+	assert.Equal(t, "'a'", u.Gen())
+
+	// suffixes are kept in the max length
+	u = NewUnique(&Choice{[]string{"'abc'"}})
+	u.SetMaxLength(4)
+	assert.Equal(t, "'abc'", u.Gen())
+	assert.Equal(t, "'ab_1'", u.Gen())
+	assert.Equal(t, "'ab_2'", u.Gen())
+	for i := 3; i < 10; i++ {
+		u.Gen()
+	}
+	assert.Equal(t, "'a_10'", u.Gen())
+	v, ok := u.withSuffix(`a\'b`, "_1")
+	assert.True(t, ok)
+	assert.Equal(t, "'a_1'", v)
+	_, ok = u.withSuffix("a", "_1000")
+	assert.False(t, ok)
+
+	// NULL is never duplicated
+	u = NewUnique(&Choice{[]string{"NULL"}})
+	assert.Equal(t, "NULL", u.Gen())
+	assert.Equal(t, "NULL", u.Gen())
+
+	_, err = Parse("unique(nothing)")
+	assert.NotEqual(t, nil, err)
+}
@@ -0,0 +1,169 @@
+package generators
+
+import (
+	"math/rand"
+	"strings"
+)
+
+// escape sequences of sql string literals
+var sqlEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`'`, `\'`,
+	"\x00", `\0`,
+	"\n", `\n`,
+	"\r", `\r`,
+	"\x1a", `\Z`,
+)
+
+// EscapeString escapes s to be spliced into a quoted sql string literal
+func EscapeString(s string) string {
+	return sqlEscaper.Replace(s)
+}
+
+// quote s as a sql string literal
+func quoteString(s string) string {
+	return `'` + EscapeString(s) + `'`
+}
+
+func runeRange(from rune, to rune) []rune {
+	runes := make([]rune, 0, to-from+1)
+	for r := from; r <= to; r++ {
+		runes = append(runes, r)
+	}
+	return runes
+}
+
+func concatRunes(runeSets ...[]rune) []rune {
+	res := make([]rune, 0)
+	for _, runes := range runeSets {
+		res = append(res, runes...)
+	}
+	return res
+}
+
+var (
+	asciiRunes = runeRange(' ', '~')
+	// printable latin1 characters, all of them can be represented by latin1
+	latin1Runes = concatRunes(asciiRunes, runeRange(0xa0, 0xff))
+	// common CJK characters, all of them can be represented by gbk
+	gbkRunes = concatRunes(asciiRunes,
+		[]rune("的一是在不了有和人这中大为上个国我以要他时来用们生到作地于出就分对成会可主发年动同工也能下过子说产种面而方后多定行学法所民得经十三之进着等部度家电力里如水化高自二理起小物现实加量都两体制机当使点从业本去把性好应开它合还因由其些然前外天政四日那社义事平形相全表间样与关各重新线内数正心反你明看原又么利比或但质气第向道命此变条只没结解问意建月公无系军很情者最立代想已通并提直题党程展五果料象员革位入常文总次品式活设及管特件长求老头基资边流路级少图山统接知较将组见计别她手角期根论运农指几九区强放决西被干做必战先回则任取据处队南给色光门即保治北造百规热领七海口东导器压志世金增争济阶油思术极交受联什认六共权收证改清己美再采转更单风切打白教速花带安场身车例真务具万每目至达走积示议声报斗完类八离华名确才科张信马节话米整空元况今集温传土许步群广石记需段研界拉林律叫且究观越织装影算低持音众书布复容儿须际商非验连断深难近矿千周委素技备半办青省列习响约支般史感劳便团往酸历市克何除消构府称太准精值号率族维划选标写存候毛亲快效斯院查江型眼王按格养易置派层片始却专状育厂京识适属圆包火住调满县局照参红细引听该铁价严"),
+	)
+	cjkRunes   = concatRunes(runeRange(0x4e00, 0x9fa5), runeRange(0x3040, 0x30ff), runeRange(0xac00, 0xd7a3))
+	emojiRunes = concatRunes(runeRange(0x1f600, 0x1f64f), runeRange(0x1f300, 0x1f5ff), runeRange(0x1f900, 0x1f9ff))
+	// combining marks are appended to a base character
+	combiningRunes = runeRange(0x0300, 0x036f)
+	utf8mb4Runes   = concatRunes(asciiRunes, latin1Runes[len(asciiRunes):], runeRange(0x0391, 0x03c9),
+		runeRange(0x0400, 0x044f), cjkRunes[:2000], emojiRunes)
+)
+
+// random string of characters in runes, with at most length characters,
+// exact means it always has length characters
+type Text struct {
+	runes []rune
+	// probability of appending a combining mark to a character
+	combining float64
+	length    int
+	exact     bool
+}
+
+func NewText(runes []rune, combining float64, length int, exact bool) *Text {
+	if length <= 0 {
+		length = 10
+	}
+	return &Text{runes, combining, length, exact}
+}
+
+func (t *Text) Gen() string {
+	n := t.length
+	if !t.exact {
+		n = randInRange(0, t.length)
+	}
+	buf := &strings.Builder{}
+	for i := 0; i < n; i++ {
+		// a combining mark is a character too
+		if i > 0 && rand.Float64() < t.combining {
+			buf.WriteRune(combiningRunes[rand.Intn(len(combiningRunes))])
+			continue
+		}
+		buf.WriteRune(t.runes[rand.Intn(len(t.runes))])
+	}
+	return quoteString(buf.String())
+}
+
+// random letters with trailing spaces, the length is at most length
+type TrailingSpace struct {
+	length int
+}
+
+func NewTrailingSpace(length int) *TrailingSpace {
+	if length <= 0 {
+		length = 10
+	}
+	return &TrailingSpace{length}
+}
+
+func (t *TrailingSpace) Gen() string {
+	spaces := randInRange(1, min(t.length, 3))
+	letters := make([]rune, randInRange(0, t.length-spaces))
+	for i := range letters {
+		letters[i] = randChars[rand.Intn(len(randChars))]
+	}
+	return quoteString(string(letters) + strings.Repeat(" ", spaces))
+}
+
+// string generators by name with max length, they are also
+// used by fields with declared length, like `varchar(20)`
+var textGens = map[string]func(length int) Generator{
+	"utf8mb4": func(length int) Generator {
+		return NewText(utf8mb4Runes, 0.1, length, false)
+	},
+	"emoji": func(length int) Generator {
+		return NewText(emojiRunes, 0, length, false)
+	},
+	"cjk": func(length int) Generator {
+		return NewText(cjkRunes, 0, length, false)
+	},
+	"gbk": func(length int) Generator {
+		return NewText(gbkRunes, 0, length, false)
+	},
+	"latin1": func(length int) Generator {
+		return NewText(latin1Runes, 0, length, false)
+	},
+	"trailing_space": func(length int) Generator {
+		return NewTrailingSpace(length)
+	},
+	"exact_length": func(length int) Generator {
+		return NewText(utf8mb4Runes, 0.1, length, true)
+	},
+}
+
+// descriptions of string generators
+var textDescs = map[string]string{
+	"utf8mb4":        "random quoted utf8mb4 string with combining marks, at most 10 or length characters",
+	"emoji":          "random quoted emoji, at most 10 or length characters",
+	"cjk":            "random quoted CJK characters, at most 10 or length characters",
+	"gbk":            "random quoted characters of gbk, at most 10 or length characters",
+	"latin1":         "random quoted characters of latin1, at most 10 or length characters",
+	"trailing_space": "random quoted letters with trailing spaces, at most 10 or length characters",
+	"exact_length":   "random quoted utf8mb4 string of exactly 10 or length characters",
+}
+
+// NewTextGen returns the string generator of name with max length,
+// nil if name is not a string generator
+func NewTextGen(name string, length int) Generator {
+	newGen, ok := textGens[name]
+	if !ok {
+		return nil
+	}
+	return newGen(length)
+}
+
+// TextGenNames returns names of string generators which respect the declared length
+func TextGenNames() []string {
+	names := make([]string, 0, len(textGens))
+	for name := range textGens {
+		names = append(names, name)
+	}
+	return names
+}
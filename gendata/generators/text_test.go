@@ -0,0 +1,52 @@
+package generators
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+var sqlUnescaper = strings.NewReplacer(`\\`, `\`, `\'`, `'`, `\0`, "\x00", `\n`, "\n", `\r`, "\r", `\Z`, "\x1a")
+
+func unquoteText(t *testing.T, literal string) string {
+	assert.True(t, len(literal) >= 2)
+	assert.Equal(t, byte('\''), literal[0])
+	assert.Equal(t, byte('\''), literal[len(literal)-1])
+	return sqlUnescaper.Replace(literal[1 : len(literal)-1])
+}
+
+func TestQuoteString(t *testing.T) {
+	assert.Equal(t, `'it\'s'`, quoteString("it's"))
+	assert.Equal(t, `'a\\b\0\n\r\Z'`, quoteString("a\\b\x00\n\r\x1a"))
+	assert.Equal(t, `'中文😀'`, quoteString("中文😀"))
+	assert.Equal(t, `it\'s`, EscapeString("it's"))
+}
+
+func TestText(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		s := unquoteText(t, NewTextGen("utf8mb4", 5).Gen())
+		assert.True(t, utf8.ValidString(s))
+		assert.True(t, utf8.RuneCountInString(s) <= 5, s)
+
+		s = unquoteText(t, NewTextGen("exact_length", 5).Gen())
+		assert.Equal(t, 5, utf8.RuneCountInString(s), s)
+
+		s = unquoteText(t, NewTextGen("emoji", 3).Gen())
+		for _, r := range s {
+			assert.Equal(t, 4, utf8.RuneLen(r))
+		}
+
+		s = unquoteText(t, NewTextGen("latin1", 8).Gen())
+		for _, r := range s {
+			assert.True(t, r <= 0xff)
+		}
+
+		s = unquoteText(t, NewTextGen("trailing_space", 4).Gen())
+		assert.True(t, strings.HasSuffix(s, " "))
+		assert.True(t, len(s) <= 4)
+	}
+
+	assert.Nil(t, NewTextGen("unknown", 1))
+	assert.NotNil(t, Get("cjk"))
+}
@@ -0,0 +1,170 @@
+package gendata
+
+import (
+	"fmt"
+	"github.com/pingcap/go-randgen/gendata/generators"
+	"github.com/yuin/gopher-lua"
+	"math/rand"
+)
+
+// table and row number of the row being generated, and the
+// first error of lua generators in the row
+type rowContext struct {
+	table string
+	row   int
+	err   error
+}
+
+// lua function in zz data, it is called per row like `f(column, row, table)`
+// and returns a sql literal, nil means NULL
+type luaGen struct {
+	l      *lua.LState
+	fn     *lua.LFunction
+	column string
+	ctx    *rowContext
+}
+
+// errors are kept in the row context, see genRows
+func (g *luaGen) Gen() string {
+	val, err := callLuaGen(g.l, g.fn, g.args())
+	if err != nil {
+		if g.ctx.err == nil {
+			g.ctx.err = fmt.Errorf("lua data generator of column %s fail, %v", g.column, err)
+		}
+		return "NULL"
+	}
+	return val
+}
+
+func (g *luaGen) args() []lua.LValue {
+	return []lua.LValue{lua.LString(g.column), lua.LNumber(g.ctx.row), lua.LString(g.ctx.table)}
+}
+
+func callLuaGen(l *lua.LState, fn *lua.LFunction, args []lua.LValue) (string, error) {
+	err := l.CallByParam(lua.P{Fn: fn, NRet: 1, Protect: true}, args...)
+	if err != nil {
+		return "", err
+	}
+	ret := l.Get(-1)
+	l.Pop(1)
+	return luaLiteral(ret), nil
+}
+
+// sql literal of value returned by lua generators
+func luaLiteral(val lua.LValue) string {
+	switch v := val.(type) {
+	case *lua.LNilType:
+		return "NULL"
+	case lua.LBool:
+		if v {
+			return "1"
+		}
+		return "0"
+	default:
+		return val.String()
+	}
+}
+
+// generator of data item in zz, function is generated by lua,
+// string is the name of a generator or a constant
+func composeFromLua(l *lua.LState, vals []lua.LValue, ctx *rowContext,
+	json *generators.JsonOptions) generators.Generator {
+	gs := make([]generators.Generator, 0, len(vals))
+	for _, val := range vals {
+		if fn, ok := val.(*lua.LFunction); ok {
+			gs = append(gs, &fieldBoundGen{func(f *fieldExec) generators.Generator {
+				return &luaGen{l: l, fn: fn, column: f.name, ctx: ctx}
+			}})
+			continue
+		}
+		gs = append(gs, composeFromGenName([]string{val.String()}, json).(*composeGen).gs...)
+	}
+	return &composeGen{gs}
+}
+
+// global of the json options of zz in the lua state, see newData
+const luaJsonOptions = "__json_options"
+
+// evaluate an argument of lua helpers with the arguments of current call
+func evalLuaArg(l *lua.LState, arg lua.LValue, args []lua.LValue) (string, error) {
+	switch v := arg.(type) {
+	case *lua.LFunction:
+		return callLuaGen(l, v, args)
+	case lua.LString:
+		var json *generators.JsonOptions
+		if ud, ok := l.GetGlobal(luaJsonOptions).(*lua.LUserData); ok {
+			json, _ = ud.Value.(*generators.JsonOptions)
+		}
+		return composeFromGenName([]string{string(v)}, json).Gen(), nil
+	default:
+		return luaLiteral(arg), nil
+	}
+}
+
+// helpers to compose generators in zz
+var luaGenFuncs = map[string]lua.LGFunction{
+	// oneof(a, b, ...) randomly evaluates one of the arguments
+	"oneof": func(l *lua.LState) int {
+		choices := luaArgs(l, 1)
+		if len(choices) == 0 {
+			l.ArgError(1, "oneof needs at least one argument")
+		}
+		l.Push(l.NewFunction(func(l *lua.LState) int {
+			val, err := evalLuaArg(l, choices[rand.Intn(len(choices))], luaArgs(l, 1))
+			if err != nil {
+				l.RaiseError("%v", err)
+			}
+			l.Push(lua.LString(val))
+			return 1
+		}))
+		return 1
+	},
+	// range(min, max[, step]) generates integers in [min, max]
+	"range": func(l *lua.LState) int {
+		min := l.CheckInt(1)
+		max := l.CheckInt(2)
+		step := l.OptInt(3, 1)
+		if max < min || step <= 0 {
+			l.ArgError(2, "range needs min <= max and positive step")
+		}
+		l.Push(l.NewFunction(func(l *lua.LState) int {
+			l.Push(lua.LNumber(min + rand.Intn((max-min)/step+1)*step))
+			return 1
+		}))
+		return 1
+	},
+	// fmt(format, a, b, ...) formats the escaped values of the evaluated arguments
+	// with `%s`, NULL is formatted as NULL, like fmt("'%s-%s'", 'letter', range(1, 9))
+	"fmt": func(l *lua.LState) int {
+		format := l.CheckString(1)
+		fmtArgs := luaArgs(l, 2)
+		l.Push(l.NewFunction(func(l *lua.LState) int {
+			args := luaArgs(l, 1)
+			vals := make([]interface{}, 0, len(fmtArgs))
+			for _, arg := range fmtArgs {
+				literal, err := evalLuaArg(l, arg, args)
+				if err != nil {
+					l.RaiseError("%v", err)
+				}
+				val, isNull := unquoteLiteral(literal)
+				if isNull {
+					vals = append(vals, "NULL")
+					continue
+				}
+				// values are spliced into quoted literals of format
+				vals = append(vals, generators.EscapeString(val))
+			}
+			l.Push(lua.LString(fmt.Sprintf(format, vals...)))
+			return 1
+		}))
+		return 1
+	},
+}
+
+func luaArgs(l *lua.LState, from int) []lua.LValue {
+	args := make([]lua.LValue, 0)
+	for i := from; i <= l.GetTop(); i++ {
+		args = append(args, l.Get(i))
+	}
+	return args
+}
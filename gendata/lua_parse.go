@@ -5,11 +5,16 @@ import (
 	"github.com/yuin/gopher-lua"
 )
 
+// the state is not closed, because lua functions in zz data are called
+// when generating rows
 func runLua(script string) (*lua.LState, error) {
 	l := lua.NewState()
-	defer l.Close()
+	for name, fn := range luaGenFuncs {
+		l.SetGlobal(name, l.NewFunction(fn))
+	}
 	err := l.DoString(script)
 	if err != nil {
+		l.Close()
 		return nil, err
 	}
 
@@ -71,3 +76,29 @@ func extractAllSlice(l *lua.LState, key string) (map[string][]string, error)  {
 	return res, err
 }
 
+// like extractAllSlice, but keep the lua values
+func extractAllValues(l *lua.LState, key string) (map[string][]lua.LValue, error) {
+	val := l.Env.RawGetString(key)
+	valTable, ok := val.(*lua.LTable)
+	if !ok {
+		return nil, fmt.Errorf("%s must be a lua Table", key)
+	}
+
+	res := make(map[string][]lua.LValue)
+	var err error
+	valTable.ForEach(func(key2 lua.LValue, value lua.LValue) {
+		table, ok := value.(*lua.LTable)
+		if !ok {
+			err = fmt.Errorf("%s.%s must be a lua Table", key, key2.String())
+			return
+		}
+
+		vals := make([]lua.LValue, 0, table.Len())
+		table.ForEach(func(_ lua.LValue, v lua.LValue) {
+			vals = append(vals, v)
+		})
+		res[key2.String()] = vals
+	})
+
+	return res, err
+}
@@ -0,0 +1,287 @@
+package gendata
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Schema is the structure of all tables in a database
+type Schema struct {
+	Tables []*TableSchema
+}
+
+type TableSchema struct {
+	Name        string
+	Columns     []*ColumnSchema
+	Indexes     []*IndexSchema
+	Partitioned bool
+}
+
+type ColumnSchema struct {
+	Name string
+	// full type name, like `int(11) unsigned`
+	Type     string
+	Nullable bool
+	// whether it is a part of primary key
+	Pk bool
+	// whether it is a part of any index, including primary key
+	Indexed bool
+}
+
+type IndexSchema struct {
+	Name    string
+	Unique  bool
+	Primary bool
+	// column names in index order
+	Columns []string
+}
+
+func (t *TableSchema) column(name string) *ColumnSchema {
+	for _, c := range t.Columns {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// LoadSchema reads the structure of all tables in the current database,
+// it supports mysql (by information_schema) and sqlite3 (by pragmas)
+func LoadSchema(db *sql.DB, dbms string) (*Schema, error) {
+	switch dbms {
+	case "mysql":
+		return loadMysqlSchema(db)
+	case "sqlite3":
+		return loadSqliteSchema(db)
+	default:
+		return nil, &dbDriverError{dbms, "Cannot retrieve the schema."}
+	}
+}
+
+func loadMysqlSchema(db *sql.DB) (*Schema, error) {
+	schema := &Schema{}
+	tables := make(map[string]*TableSchema)
+
+	err := queryEach(db, "select table_name from information_schema.tables "+
+		"where table_schema = database() and table_type = 'BASE TABLE' order by table_name",
+		func(rows *sql.Rows) error {
+			table := &TableSchema{}
+			if err := rows.Scan(&table.Name); err != nil {
+				return err
+			}
+			tables[table.Name] = table
+			schema.Tables = append(schema.Tables, table)
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	err = queryEach(db, "select table_name, column_name, column_type, is_nullable, column_key "+
+		"from information_schema.columns where table_schema = database() "+
+		"order by table_name, ordinal_position",
+		func(rows *sql.Rows) error {
+			var tableName, nullable, key string
+			column := &ColumnSchema{}
+			if err := rows.Scan(&tableName, &column.Name, &column.Type, &nullable, &key); err != nil {
+				return err
+			}
+			table, ok := tables[tableName]
+			if !ok {
+				// views
+				return nil
+			}
+			column.Nullable = nullable == "YES"
+			column.Pk = key == "PRI"
+			column.Indexed = key != ""
+			table.Columns = append(table.Columns, column)
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	err = queryEach(db, "select table_name, index_name, non_unique, column_name "+
+		"from information_schema.statistics where table_schema = database() "+
+		"order by table_name, index_name, seq_in_index",
+		func(rows *sql.Rows) error {
+			var tableName, indexName, columnName string
+			var nonUnique int
+			if err := rows.Scan(&tableName, &indexName, &nonUnique, &columnName); err != nil {
+				return err
+			}
+			table, ok := tables[tableName]
+			if !ok {
+				return nil
+			}
+			addIndexColumn(table, indexName, nonUnique == 0, indexName == "PRIMARY", columnName)
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	err = queryEach(db, "select distinct table_name from information_schema.partitions "+
+		"where table_schema = database() and partition_name is not null",
+		func(rows *sql.Rows) error {
+			var tableName string
+			if err := rows.Scan(&tableName); err != nil {
+				return err
+			}
+			if table, ok := tables[tableName]; ok {
+				table.Partitioned = true
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	return schema, nil
+}
+
+func loadSqliteSchema(db *sql.DB) (*Schema, error) {
+	schema := &Schema{}
+
+	err := queryEach(db, "SELECT name FROM sqlite_master WHERE type='table' "+
+		"AND name NOT LIKE 'sqlite_%' ORDER BY name;",
+		func(rows *sql.Rows) error {
+			table := &TableSchema{}
+			if err := rows.Scan(&table.Name); err != nil {
+				return err
+			}
+			schema.Tables = append(schema.Tables, table)
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, table := range schema.Tables {
+		err = queryEach(db, fmt.Sprintf("PRAGMA table_info('%s');", table.Name),
+			func(rows *sql.Rows) error {
+				var notNull, pk int
+				column := &ColumnSchema{}
+				if err := rows.Scan(&sql.RawBytes{}, &column.Name, &column.Type,
+					&notNull, &sql.RawBytes{}, &pk); err != nil {
+					return err
+				}
+				column.Nullable = notNull == 0
+				column.Pk = pk > 0
+				column.Indexed = column.Pk
+				table.Columns = append(table.Columns, column)
+				return nil
+			})
+		if err != nil {
+			return nil, err
+		}
+
+		type sqliteIndex struct {
+			name    string
+			unique  bool
+			primary bool
+		}
+		indexes := make([]*sqliteIndex, 0)
+		err = queryEach(db, fmt.Sprintf("PRAGMA index_list('%s');", table.Name),
+			func(rows *sql.Rows) error {
+				var unique int
+				var origin string
+				index := &sqliteIndex{}
+				if err := rows.Scan(&sql.RawBytes{}, &index.name, &unique,
+					&origin, &sql.RawBytes{}); err != nil {
+					return err
+				}
+				index.unique = unique != 0
+				index.primary = origin == "pk"
+				indexes = append(indexes, index)
+				return nil
+			})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, index := range indexes {
+			err = queryEach(db, fmt.Sprintf("PRAGMA index_info('%s');", index.name),
+				func(rows *sql.Rows) error {
+					var columnName string
+					if err := rows.Scan(&sql.RawBytes{}, &sql.RawBytes{}, &columnName); err != nil {
+						return err
+					}
+					addIndexColumn(table, index.name, index.unique, index.primary, columnName)
+					return nil
+				})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return schema, nil
+}
+
+func addIndexColumn(table *TableSchema, indexName string, unique bool, primary bool,
+	columnName string) {
+	if column := table.column(columnName); column != nil {
+		column.Indexed = true
+		if primary {
+			column.Pk = true
+		}
+	}
+
+	for _, index := range table.Indexes {
+		if index.Name == indexName {
+			index.Columns = append(index.Columns, columnName)
+			return
+		}
+	}
+
+	table.Indexes = append(table.Indexes, &IndexSchema{
+		Name:    indexName,
+		Unique:  unique,
+		Primary: primary,
+		Columns: []string{columnName},
+	})
+}
+
+// rows are closed before return, so that the next query
+// can reuse the connection
+func queryEach(db *sql.DB, query string, handler func(rows *sql.Rows) error) error {
+	rows, err := db.Query(query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := handler(rows); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (s *Schema) tableStmts() []*tableStmt {
+	stmts := make([]*tableStmt, 0, len(s.Tables))
+	for _, table := range s.Tables {
+		stmt := &tableStmt{
+			name:        table.Name,
+			partitioned: table.Partitioned,
+			fields:      make([]*fieldExec, 0, len(table.Columns)),
+		}
+		for _, column := range table.Columns {
+			tp := strings.ToLower(column.Type)
+			stmt.fields = append(stmt.fields, &fieldExec{
+				name:     column.Name,
+				tp:       tp,
+				unsign:   strings.Contains(tp, "unsigned"),
+				nullable: column.Nullable,
+				pk:       column.Pk,
+				indexed:  column.Indexed,
+			})
+		}
+		stmts = append(stmts, stmt)
+	}
+	return stmts
+}
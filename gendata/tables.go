@@ -14,7 +14,7 @@ type Tables struct {
 
 var tablesTmpl = mustParse("tables", "create table {{.tname}} (\n" +
 "`pk` int primary key%s\n" +
-") {{.charsets}} {{.partitions}}")
+") {{.charsets}} {{.collations}} {{.partitions}}")
 
 // support vars
 var tablesVars = []*varWithDefault{
@@ -26,6 +26,10 @@ var tablesVars = []*varWithDefault{
 		"charsets",
 		[]string{"undef"},
 	},
+	{
+		"collations",
+		[]string{"undef"},
+	},
 	{
 		"partitions",
 		[]string{"undef"},
@@ -47,8 +51,27 @@ var tableFuncs = map[string]func(string, *tableStmt) (string, error){
 		if text == "undef" {
 			return "", nil
 		}
+		stmt.charset = text
 		return fmt.Sprintf("character set %s", text), nil
 	},
+	// charsets must be processed before it
+	"collations": func(text string, stmt *tableStmt) (s string, e error) {
+		charset := stmt.charset
+		if charset == "" {
+			charset = "undef"
+		}
+		if err := checkCollation(charset, text); err != nil {
+			return "", err
+		}
+		if text == "undef" {
+			return "", nil
+		}
+		stmt.collation = text
+		if stmt.charset == "" {
+			stmt.charset, _ = collationCharset(text)
+		}
+		return fmt.Sprintf("collate %s", text), nil
+	},
 	"partitions": func(text string, stmt *tableStmt) (s string, e error) {
 		if text == "undef" {
 			return "", nil
@@ -57,6 +80,7 @@ var tableFuncs = map[string]func(string, *tableStmt) (string, error){
 		if err != nil {
 			return "", err
 		}
+		stmt.partitioned = true
 		return fmt.Sprintf("\npartition by hash(pk)\npartitions %d", num), nil
 	},
 }
@@ -77,6 +101,7 @@ func (t *Tables) gen() ([]*tableStmt, error) {
 	buf := &bytes.Buffer{}
 	m := make(map[string]string)
 	stmts := make([]*tableStmt, 0, t.numbers)
+	names := make(uniqueNames)
 
 	err := t.traverse(func(cur []string) error {
 		buf.Reset()
@@ -86,17 +111,24 @@ func (t *Tables) gen() ([]*tableStmt, error) {
 			// current field name: fields[i]
 			// current field value: curr[i]
 			field := t.fields[i]
-			buf.WriteString("_" + cur[i])
+			// keep names of tables without collation unchanged
+			if field != "collations" || cur[i] != "undef" {
+				buf.WriteString("_" + cur[i])
+			}
 			target, err := tableFuncs[field](cur[i], stmt)
+			if err == errIncompatibleCollation {
+				return nil
+			}
 			if err != nil {
 				return err
 			}
 			m[field] = target
 		}
 
-		tname := buf.String()
+		tname := names.get(buf.String())
 
 		stmt.name = tname
+		stmt.extraFields = []*fieldExec{zzPkField}
 
 		m["tname"] = tname
 
@@ -120,10 +152,21 @@ type tableStmt struct {
 	// table name
 	name string
 	rowNum int
+	// character set and collation, "" means default
+	charset   string
+	collation string
 	// generate by wrapInTable
 	ddl string
+	partitioned bool
+	// fields of this table, nil means that all tables have the same fields
+	fields []*fieldExec
+	// columns not generated by zz fields, they are only used by index related keyfuns
+	extraFields []*fieldExec
 }
 
+// the `pk` column in tablesTmpl
+var zzPkField = &fieldExec{name: "pk", tp: "int", pk: true, indexed: true}
+
 func (t *tableStmt) wrapInTable(fieldStmts []string) {
 	buf := &bytes.Buffer{}
 	buf.WriteString(",\n")
@@ -2,6 +2,7 @@ package gendata
 
 import (
 	"github.com/stretchr/testify/assert"
+	"strings"
 	"testing"
 )
 
@@ -30,3 +31,55 @@ tables = {
 		fmt.Println(stmt.rowNum)
 	}*/
 }
+
+func TestTableCollations(t *testing.T) {
+	l, err := runLua(`
+tables = {
+    rows = {10},
+    charsets = {'utf8mb4', 'latin1', 'undef'},
+    collations = {'utf8mb4_bin', 'latin1_bin', 'undef'},
+}
+`)
+	assert.Equal(t, nil, err)
+
+	tables, err := newTables(l)
+	assert.Equal(t, nil, err)
+
+	stmts, err := tables.gen()
+	assert.Equal(t, nil, err)
+
+	// incompatible combinations are skipped
+	names := make([]string, 0, len(stmts))
+	for _, stmt := range stmts {
+		names = append(names, stmt.name)
+	}
+	assert.Equal(t, []string{
+		"table_10_utf8mb4_utf8mb4_bin_undef", "table_10_utf8mb4_undef",
+		"table_10_latin1_latin1_bin_undef", "table_10_latin1_undef",
+		"table_10_undef_utf8mb4_bin_undef", "table_10_undef_latin1_bin_undef", "table_10_undef_undef",
+	}, names)
+	assert.Equal(t, "utf8mb4_bin", stmts[0].collation)
+	assert.Contains(t, stmts[0].format, "character set utf8mb4 collate utf8mb4_bin")
+
+	l, err = runLua(`
+tables = {
+    collations = {'utf8mb4'},
+}
+`)
+	assert.Equal(t, nil, err)
+	tables, err = newTables(l)
+	assert.Equal(t, nil, err)
+	_, err = tables.gen()
+	assert.Equal(t, "illegal collation utf8mb4", err.Error())
+}
+
+func TestUniqueNames(t *testing.T) {
+	names := make(uniqueNames)
+	assert.Equal(t, "a", names.get("a"))
+	assert.Equal(t, "a_1", names.get("a"))
+	assert.Equal(t, "a_2", names.get("a"))
+
+	long := names.get(strings.Repeat("x", 100))
+	assert.Equal(t, maxNameLength, len(long))
+	assert.NotEqual(t, long, names.get(strings.Repeat("x", 100)))
+}
@@ -187,6 +187,65 @@ query:{table = _table()}
 
 }
 
+func TestInvariant(t *testing.T) {
+	counter := 0
+	keyFuncs := map[string]func() (string, error){
+		"_k": func() (string, error) {
+			counter++
+			return fmt.Sprintf("%d", counter), nil
+		},
+	}
+
+	iter, err := NewIter(`
+query:
+    _k_invariant _k_invariant _k_invariant2 _k_invariant_txn ;
+    _k_invariant _k_invariant_txn _k_invariant_test
+`, "query", 5, keyFuncs, false)
+	assert.Equal(t, nil, err)
+
+	expSeq := []string{
+		"1 1 2 3",
+		"4 3 5",
+		"6 6 7 8",
+		"9 8 5",
+	}
+	err = iter.Visit(sql_generator.FixedTimesVisitor(func(i int, sql string) {
+		assert.Equal(t, expSeq[i], sql)
+	}, len(expSeq)))
+	assert.Equal(t, nil, err)
+
+	// test scope is reset
+	iter.(*sql_generator.SQLRandomlyIterator).ResetInvariants()
+	expSeq = []string{
+		"10 10 11 12",
+		"13 12 14",
+	}
+	err = iter.Visit(sql_generator.FixedTimesVisitor(func(i int, sql string) {
+		assert.Equal(t, expSeq[i], sql)
+	}, len(expSeq)))
+	assert.Equal(t, nil, err)
+
+	// invariant of an unknown keyword
+	iter, err = NewIter(`query: _unknown_invariant`, "query", 5, keyFuncs, false)
+	assert.Equal(t, nil, err)
+	err = iter.Visit(sql_generator.FixedTimesVisitor(func(i int, sql string) {}, 1))
+	assert.Equal(t, "'_unknown_invariant' key word not support", err.Error())
+}
+
+func TestParamKeyword(t *testing.T) {
+	iter, err := NewIter(`query: _int(1,3) _choice('a', 'b')`, "query", 5, nil, false)
+	assert.Equal(t, nil, err)
+	err = iter.Visit(sql_generator.FixedTimesVisitor(func(i int, sql string) {
+		assert.Regexp(t, `^[1-3] '[ab]'$`, sql)
+	}, 20))
+	assert.Equal(t, nil, err)
+
+	iter, err = NewIter(`query: _int(3,1)`, "query", 5, nil, false)
+	assert.Equal(t, nil, err)
+	err = iter.Visit(sql_generator.FixedTimesVisitor(func(i int, sql string) {}, 1))
+	assert.Contains(t, err.Error(), "'_int(3,1)' key word not support")
+}
+
 func TestMaxRetry(t *testing.T) {
 	recurYy := `
 query:
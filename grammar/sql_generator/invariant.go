@@ -0,0 +1,73 @@
+package sql_generator
+
+import "regexp"
+
+// scopes of invariant keywords
+const (
+	// reset after every sql
+	invariantStmt = iota
+	// reset after every expansion of the root production
+	invariantTxn
+	// reset only by ResetInvariants
+	invariantTest
+)
+
+var invariantScopes = map[string]int{
+	"":      invariantStmt,
+	"_txn":  invariantTxn,
+	"_test": invariantTest,
+}
+
+// invariant form of keyword: `_field_int_invariant`, `_field_invariant2_txn`, `_table_invariant_test`,
+// group 1 is the keyword, group 2 distinguishes several invariants of the same keyword,
+// group 3 is the scope
+var invariantPattern = regexp.MustCompile(`^(_\w+?)_invariant(\d*)(_txn|_test)?$`)
+
+// invariants keeps the value of invariant keywords in their scope
+type invariants struct {
+	values [invariantTest + 1]map[string]string
+}
+
+func newInvariants() *invariants {
+	inv := &invariants{}
+	for scope := range inv.values {
+		inv.values[scope] = make(map[string]string)
+	}
+	return inv
+}
+
+// reset values of scope and narrower scopes
+func (inv *invariants) reset(scope int) {
+	for s := invariantStmt; s <= scope; s++ {
+		for k := range inv.values[s] {
+			delete(inv.values[s], k)
+		}
+	}
+}
+
+// gen returns the value of an invariant keyword, ok is false if key
+// is not in invariant form or its keyword is not supported
+func (inv *invariants) gen(keyFuncs KeyFuncs, key string) (res string, ok bool, err error) {
+	matches := invariantPattern.FindStringSubmatch(key)
+	if matches == nil {
+		return "", false, nil
+	}
+
+	kf, ok := keyFuncs[matches[1]]
+	if !ok {
+		return "", false, nil
+	}
+
+	values := inv.values[invariantScopes[matches[3]]]
+	if res, ok := values[key]; ok {
+		return res, true, nil
+	}
+
+	res, err = kf()
+	if err != nil {
+		return res, true, err
+	}
+	values[key] = res
+
+	return res, true, nil
+}
@@ -7,8 +7,10 @@ import (
 	"io"
 	"log"
 	"math/rand"
+	"strings"
 	"time"
 
+	"github.com/pingcap/go-randgen/gendata/generators"
 	"github.com/pingcap/go-randgen/grammar/yacc_parser"
 	lua "github.com/yuin/gopher-lua"
 )
@@ -95,6 +97,9 @@ type SQLRandomlyIterator struct {
 	maxRecursive int
 	rng          *rand.Rand
 	debug        bool
+	invariants   *invariants
+	// parsed parameterized keywords, like `_int(1,10)`
+	paramGens map[string]generators.Generator
 }
 
 func NewSQLGen(yy string, fs KeyFuncs, setup func(*lua.LState, io.Writer) error) (*SQLRandomlyIterator, error) {
@@ -119,6 +124,8 @@ func NewSQLGen(yy string, fs KeyFuncs, setup func(*lua.LState, io.Writer) error)
 		pathInfo:      newPathInfo(),
 		rng:           rand.New(rand.NewSource(time.Now().UnixNano())),
 		maxRecursive:  15,
+		invariants:    newInvariants(),
+		paramGens:     make(map[string]generators.Generator),
 	}
 	if err = setup(it.luaVM, it.printBuf); err != nil {
 		return nil, err
@@ -155,18 +162,54 @@ func (i *SQLRandomlyIterator) PathInfo() *PathInfo {
 	return i.pathInfo
 }
 
+// ResetInvariants resets invariant keywords of all scopes,
+// call it when a new test starts
+func (i *SQLRandomlyIterator) ResetInvariants() {
+	i.invariants.reset(invariantTest)
+}
+
+// generate keyword, keyFuncs have priority over invariant forms
+func (i *SQLRandomlyIterator) genKeyword(key string) (string, bool, error) {
+	if res, ok, err := i.keyFuncs.Gen(key); ok {
+		return res, ok, err
+	}
+
+	if strings.HasSuffix(key, ")") {
+		return i.genParamKeyword(key)
+	}
+
+	return i.invariants.gen(i.keyFuncs, key)
+}
+
+// parameterized keyword is parsed once by generators.Parse,
+// `_int(1,10)` -> `int(1,10)`
+func (i *SQLRandomlyIterator) genParamKeyword(key string) (string, bool, error) {
+	g, ok := i.paramGens[key]
+	if !ok {
+		var err error
+		g, err = generators.Parse(key[1:])
+		if err != nil {
+			return "", true, fmt.Errorf("'%s' key word not support, %v", key, err)
+		}
+		i.paramGens[key] = g
+	}
+	return g.Gen(), true, nil
+}
+
 // visitor sqls generted by the iterator
 func (i *SQLRandomlyIterator) Visit(visitor SqlVisitor) error {
 
 	wrapper := func(sql string) bool {
 		res := visitor(sql)
 		i.pathInfo.clear()
+		i.invariants.reset(invariantStmt)
 		return res
 	}
 
 	sqlBuffer := &bytes.Buffer{}
 
 	for {
+		i.invariants.reset(invariantTxn)
 		_, err := i.generateSQLRandomly(i.productionName, newLinkedMap(), sqlBuffer,
 			false, wrapper)
 		if err != nil && err != normalStop {
@@ -237,6 +280,8 @@ func GenerateSQLRandomly(headCodeBlocks []*yacc_parser.CodeBlock,
 		pathInfo:       newPathInfo(),
 		rng:            rng,
 		debug:          debug,
+		invariants:     newInvariants(),
+		paramGens:      make(map[string]generators.Generator),
 	}, nil
 }
 
@@ -361,7 +406,7 @@ func (i *SQLRandomlyIterator) generateSQLRandomly(productionName string,
 			}
 
 			// key word parse
-			if res, ok, err := i.keyFuncs.Gen(item.OriginString()); err != nil {
+			if res, ok, err := i.genKeyword(item.OriginString()); err != nil {
 				return !firstWrite, err
 			} else if ok {
 				i.printDebugInfo(res, recurCounter)
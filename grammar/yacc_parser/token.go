@@ -2,6 +2,7 @@ package yacc_parser
 
 import (
 	"io"
+	"regexp"
 	"unicode"
 
 	"github.com/emirpasic/gods/stacks/arraystack"
@@ -306,6 +307,13 @@ func Tokenize(reader *RuneSeq) func() (Token, error) {
 					state = inComment
 				}
 			case inKeyWord:
+				// argument list of parameterized keyword, like `_int(1,10)`
+				if err != io.EOF && r == '(' && !reader.LastEqual('_') {
+					if end, ok := scanKeywordArgs(reader); ok {
+						reader.SetPos(end)
+						return &keyword{common, reader.Slice(lookBackPos)}, nil
+					}
+				}
 				if err == io.EOF || tknEnd(reader, r) {
 					if err != io.EOF {
 						reader.UnreadRune()
@@ -455,6 +463,35 @@ func Tokenize(reader *RuneSeq) func() (Token, error) {
 	}
 }
 
+var keywordArgsPattern = regexp.MustCompile(`^\s*` + keywordArg + `(\s*,\s*` + keywordArg + `)*\s*$`)
+
+const keywordArg = `(-?\d+(\.\d+)?([eE][-+]?\d+)?|'([^'\\]|\\.)*'|"([^"\\]|\\.)*")`
+
+// scanKeywordArgs checks whether the runes after '(' are an argument
+// list of numbers and quoted strings, end is the position after ')',
+// so `_table(pk, col_int)` is still a keyword followed by terminals
+func scanKeywordArgs(reader *RuneSeq) (end int, ok bool) {
+	var quote rune
+	for i := reader.Pos; i < len(reader.Runes); i++ {
+		r := reader.Runes[i]
+		switch {
+		case quote != 0:
+			if r == '\\' {
+				i++
+			} else if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '\n':
+			return 0, false
+		case r == ')':
+			return i + 1, keywordArgsPattern.MatchString(string(reader.Runes[reader.Pos:i]))
+		}
+	}
+	return 0, false
+}
+
 var specialRune = map[rune]bool{
 	',': true,
 	';': true,
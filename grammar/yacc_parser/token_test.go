@@ -134,6 +134,10 @@ asd
 `,
 			[]string{"{\n-- {\n--[==[\n}\n]==]\nasd\n}"},
 		},
+		{
+			`q: _int(1, 10) _choice('a', "b") _table(pk, col) _t(`,
+			[]string{"q", ":", "_int(1, 10)", `_choice('a', "b")`, "_table", "(", "pk", ",", "col", ")", "_t", "("},
+		},
 	}
 
 	for _, originAndExpec := range originAndExpecs {
@@ -11,6 +11,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/pingcap/go-randgen/compare"
 	"github.com/spf13/cobra"
 	"github.com/zyguan/sqlz/resultset"
 	"golang.org/x/sync/errgroup"
@@ -123,10 +124,12 @@ func genTestCmd(g *global) *cobra.Command {
 
 func runTestCmd(g *global) *cobra.Command {
 	var (
-		opts runABTestOptions
-		dsn1 string
-		dsn2 string
-		test uint32
+		opts     runABTestOptions
+		dsn1     string
+		dsn2     string
+		test     uint32
+		errCodes string
+		ignore   []uint
 	)
 
 	cmd := &cobra.Command{
@@ -136,6 +139,9 @@ func runTestCmd(g *global) *cobra.Command {
 		SilenceUsage:  true,
 		RunE: func(cmd *cobra.Command, args []string) (err error) {
 			opts.Store = g.store
+			if err = g.store.Migrate(); err != nil {
+				return
+			}
 			if opts.Threads <= 0 {
 				opts.Threads = 1
 			}
@@ -145,6 +151,14 @@ func runTestCmd(g *global) *cobra.Command {
 			if opts.DB2, err = sql.Open("mysql", dsn2); err != nil {
 				return
 			}
+			if errCodes != "" {
+				if opts.ErrCodes, err = compare.LoadErrCodes(errCodes); err != nil {
+					return
+				}
+			}
+			for _, code := range ignore {
+				opts.IgnoreWarnings = append(opts.IgnoreWarnings, uint16(code))
+			}
 			if test > 0 {
 				var cnt uint32
 				opts.Continue = func() bool {
@@ -167,6 +181,9 @@ func runTestCmd(g *global) *cobra.Command {
 	cmd.Flags().StringSliceVar(&opts.TiFlashTables, "tiflash-tables", []string{}, "tables needed to wait for replication available, eg. 1:t1,1:t2")
 	cmd.Flags().IntVar(&opts.Threads, "thread", 1, "number of worker threads")
 	cmd.Flags().IntVar(&opts.QueryTimeout, "query-timeout", 30, "timeout in seconds for a singe query")
+	cmd.Flags().StringVar(&errCodes, "err-codes", "", "json file declaring equivalent and ignorable error codes of two databases")
+	cmd.Flags().BoolVar(&opts.Warnings, "warnings", false, "compare level, code and count of SHOW WARNINGS after every statement")
+	cmd.Flags().UintSliceVar(&ignore, "ignore-warnings", []uint{}, "warning codes skipped when comparing warnings, eg. 1292,1366")
 	return cmd
 }
 
@@ -179,6 +196,9 @@ func whyTestCmd(g *global) *cobra.Command {
 		Args:          cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			id := args[0]
+			if err := g.store.Migrate(); err != nil {
+				return err
+			}
 			db, err := sql.Open("mysql", g.storeDSN)
 			if err != nil {
 				return err
@@ -207,8 +227,17 @@ func whyTestCmd(g *global) *cobra.Command {
 				return nil
 			}
 
-			dumpRes := func(tag string, raw []byte, err string) {
+			dumpRes := func(tag string, raw []byte, err string, warningsRaw []byte) {
 				fmt.Println("\n**" + tag + "**")
+				if len(warningsRaw) > 0 {
+					var warnings []*compare.Warning
+					if e := json.Unmarshal(warningsRaw, &warnings); e != nil {
+						fmt.Println("oops: " + e.Error())
+					}
+					for _, w := range warnings {
+						fmt.Println(w.String())
+					}
+				}
 				if len(err) > 0 {
 					fmt.Println("Error: " + err)
 					return
@@ -271,19 +300,20 @@ func whyTestCmd(g *global) *cobra.Command {
 			fmt.Printf("\n%d: %s\n", seq, stmt)
 
 			fmt.Println("\n```")
-			rows, err := db.Query("select tag, result, errmsg from stmt_result where test_id = ? and seq = ? order by tag", id, seq)
+			rows, err := db.Query("select tag, result, errmsg, warnings from stmt_result where test_id = ? and seq = ? order by tag", id, seq)
 			if err != nil {
 				fmt.Println("oops: " + err.Error())
 			} else {
 				defer rows.Close()
 				for rows.Next() {
 					var (
-						tag string
-						err string
-						raw []byte
+						tag      string
+						err      string
+						raw      []byte
+						warnings []byte
 					)
-					if rows.Scan(&tag, &raw, &err) == nil {
-						dumpRes(tag, raw, err)
+					if rows.Scan(&tag, &raw, &err, &warnings) == nil {
+						dumpRes(tag, raw, err, warnings)
 					}
 				}
 			}
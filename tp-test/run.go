@@ -6,6 +6,7 @@ import (
 	"crypto/sha1"
 	"database/sql"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
@@ -16,6 +17,7 @@ import (
 	"time"
 
 	"github.com/go-sql-driver/mysql"
+	"github.com/pingcap/go-randgen/compare"
 	"github.com/zyguan/sqlz"
 	"github.com/zyguan/sqlz/resultset"
 
@@ -34,6 +36,12 @@ type runABTestOptions struct {
 
 	TiFlashTables []string
 
+	// equivalent and ignorable error codes of two databases
+	ErrCodes *compare.ErrCodes
+	// compare SHOW WARNINGS after every statement
+	Warnings       bool
+	IgnoreWarnings []uint16
+
 	Store Store
 }
 
@@ -102,6 +110,7 @@ func runABTest(ctx context.Context, failed chan struct{}, opts runABTestOptions)
 		}
 
 		log.Printf("run test %s", t.ID)
+		timedOut := false
 		for i := range t.Steps {
 			tx1, err1 := conn1.BeginTx(ctx, nil)
 			if err1 != nil {
@@ -127,11 +136,27 @@ func runABTest(ctx context.Context, failed chan struct{}, opts runABTestOptions)
 			}
 
 			if err := doTxn(ctx, opts, t, i, tx1, tx2); err != nil {
+				if _, ok := err.(timeoutErr); ok {
+					tx1.Rollback()
+					tx2.Rollback()
+					closeConns()
+					store.SetTest(t.ID, TestTimeout, err.Error())
+					log.Printf("test(%s) timeout at txn #%d: %v", t.ID, i, err)
+					// the timed out connections are closed, drop databases on new ones
+					if _, e := opts.DB1.ExecContext(ctx, "drop database if exists "+dbName1); e != nil {
+						log.Printf("failed to drop %s/%s: %v", opts.Tag1, dbName1, e)
+					}
+					if _, e := opts.DB2.ExecContext(ctx, "drop database if exists "+dbName2); e != nil {
+						log.Printf("failed to drop %s/%s: %v", opts.Tag2, dbName2, e)
+					}
+					timedOut = true
+					break
+				}
 				return fail(err)
 			}
 
 			err1, err2 = tx1.Commit(), tx2.Commit()
-			if !validateErrs(err1, err2) {
+			if !opts.ErrCodes.Consistent(err1, err2) {
 				return fail(fmt.Errorf("commit txn #%d: %v <> %v", i, err1, err2))
 			}
 
@@ -149,6 +174,9 @@ func runABTest(ctx context.Context, failed chan struct{}, opts runABTestOptions)
 				}
 			}
 		}
+		if timedOut {
+			continue
+		}
 
 		store.SetTest(t.ID, TestPassed, "")
 
@@ -226,46 +254,90 @@ func checkTable(ctx context.Context, db *sql.DB, name string) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	return unorderedDigest(rs, func(col resultset.ColumnDef) bool {
-		return col.Type != "JSON"
-	}), nil
+	return unorderedDigest(rs, nil), nil
+}
+
+// timeoutErr means a statement times out on both databases
+type timeoutErr struct {
+	error
 }
 
 func doTxn(ctx context.Context, opts runABTestOptions, t *Test, i int, tx1 *sql.Tx, tx2 *sql.Tx) error {
 	txn := t.Steps[i]
 
-	record := func(seq int, tag string, rs *resultset.ResultSet, err error) {
+	record := func(seq int, tag string, rs *resultset.ResultSet, err error, warnings []*compare.Warning) {
+		res := Result{Err: err, Warnings: warnings}
 		if rs != nil {
-			raw, _ := rs.Encode()
-			opts.Store.PutStmtResult(t.ID, seq, tag, Result{
-				Raw:          raw,
-				Err:          err,
-				RowsAffected: rs.ExecResult().RowsAffected,
-				LastInsertId: rs.ExecResult().LastInsertId,
-			})
-		} else {
-			opts.Store.PutStmtResult(t.ID, seq, tag, Result{Err: err})
+			res.Raw, _ = rs.Encode()
+			res.RowsAffected = rs.ExecResult().RowsAffected
+			res.LastInsertId = rs.ExecResult().LastInsertId
+		}
+		if e := opts.Store.PutStmtResult(t.ID, seq, tag, res); e != nil {
+			log.Printf("failed to put result of test(%s) #%d on %s: %v", t.ID, seq, tag, e)
 		}
 	}
 
+	showWarnings := func(ctx context.Context, tx *sql.Tx, tag string) []*compare.Warning {
+		if !opts.Warnings {
+			return nil
+		}
+		warnings, err := compare.ShowWarnings(ctx, tx)
+		if err != nil {
+			log.Printf("show warnings on %s: %v", tag, err)
+		}
+		return warnings
+	}
+
+	connID := func(tx *sql.Tx, tag string) int64 {
+		id, err := compare.ConnectionID(ctx, tx)
+		if err != nil {
+			log.Printf("get connection id on %s: %v", tag, err)
+		}
+		return id
+	}
+	id1, id2 := connID(tx1, opts.Tag1), connID(tx2, opts.Tag2)
+
+	// run stmt with the query timeout, it is killed by a side connection of db if it times out
+	runStmt := func(db *sql.DB, tx *sql.Tx, id int64, stmt Stmt, tag string) (*resultset.ResultSet, []*compare.Warning, error) {
+		stmtCtx, cancel := context.WithTimeout(ctx, time.Duration(opts.QueryTimeout)*time.Second)
+		defer cancel()
+		rs, err := doStmt(stmtCtx, tx, stmt)
+		if err != nil && stmtCtx.Err() == context.DeadlineExceeded {
+			if id != 0 {
+				if err := compare.KillQuery(db, id); err != nil {
+					log.Printf("kill query of connection %d on %s: %v", id, tag, err)
+				}
+			}
+			return nil, nil, compare.ErrTimeout
+		}
+		return rs, showWarnings(stmtCtx, tx, tag), err
+	}
+
 	for _, stmt := range txn {
-		ctx1, _ := context.WithTimeout(ctx, time.Duration(opts.QueryTimeout)*time.Second)
-		rs1, err1 := doStmt(ctx1, tx1, stmt)
-		record(stmt.Seq, opts.Tag1, rs1, err1)
-		ctx2, _ := context.WithTimeout(ctx, time.Duration(opts.QueryTimeout)*time.Second)
-		rs2, err2 := doStmt(ctx2, tx2, stmt)
-		record(stmt.Seq, opts.Tag2, rs2, err2)
-		if !validateErrs(err1, err2) {
-			return fmt.Errorf("errors mismatch: %v <> %v @(%s,%d) %q", err1, err2, t.ID, stmt.Seq, stmt.Stmt)
+		rs1, ws1, err1 := runStmt(opts.DB1, tx1, id1, stmt, opts.Tag1)
+		record(stmt.Seq, opts.Tag1, rs1, err1, ws1)
+		rs2, ws2, err2 := runStmt(opts.DB2, tx2, id2, stmt, opts.Tag2)
+		record(stmt.Seq, opts.Tag2, rs2, err2, ws2)
+		if err1 == compare.ErrTimeout || err2 == compare.ErrTimeout {
+			if err1 != err2 {
+				return fmt.Errorf("timeout mismatch: %v <> %v @(%s,%d) %q",
+					err1, err2, t.ID, stmt.Seq, stmt.Stmt)
+			}
+			// connections are broken after timeout, the test can not go on
+			return timeoutErr{fmt.Errorf("statement timeout on both %s and %s @(%s,%d) %q",
+				opts.Tag1, opts.Tag2, t.ID, stmt.Seq, stmt.Stmt)}
+		}
+		if !opts.ErrCodes.Consistent(err1, err2) {
+			return fmt.Errorf("errors mismatch: %v <> %v @(%s,%d) %q",
+				err1, err2, t.ID, stmt.Seq, stmt.Stmt)
 		}
 		if rs1 == nil || rs2 == nil {
 			log.Printf("skip query error: [%v] [%v] @(%s,%d)", err1, err2, t.ID, stmt.Seq)
 			continue
 		}
 		h1, h2 := "", ""
-		if q := strings.ToLower(stmt.Stmt); stmt.IsQuery && rs1.NRows() == rs2.NRows() && rs1.NRows() > 1 &&
-			(!strings.Contains(q, "order by") || strings.Contains(q, "force-unordered")) {
-			h1, h2 = unorderedDigest(rs1, nil), unorderedDigest(rs2, nil)
+		if stmt.IsQuery && rs1.NRows() == rs2.NRows() && rs1.NRows() > 1 {
+			h1, h2 = queryDigest(stmt.Stmt, rs1), queryDigest(stmt.Stmt, rs2)
 		} else {
 			h1, h2 = rs1.DataDigest(), rs2.DataDigest()
 		}
@@ -276,6 +348,9 @@ func doTxn(ctx context.Context, opts runABTestOptions, t *Test, i int, tx1 *sql.
 			return fmt.Errorf("rows affected mismatch: %d != %d @(%s,%d) %q",
 				rs1.ExecResult().RowsAffected, rs2.ExecResult().RowsAffected, t.ID, stmt.Seq, stmt.Stmt)
 		}
+		if diffs := compare.WarningDiffs(ws1, ws2, opts.IgnoreWarnings); opts.Warnings && len(diffs) > 0 {
+			return fmt.Errorf("warnings mismatch: %s @(%s,%d) %q", strings.Join(diffs, ", "), t.ID, stmt.Seq, stmt.Stmt)
+		}
 	}
 	return nil
 }
@@ -288,6 +363,79 @@ func (r rows) Less(i, j int) bool { return bytes.Compare(r[i], r[j]) < 0 }
 
 func (r rows) Swap(i, j int) { r[i], r[j] = r[j], r[i] }
 
+// queryDigest digests rows in the order of the outermost ORDER BY of
+// query, rows are unordered if there is not one or it is force-unordered,
+// and fully ordered if its sort keys are not in the result
+func queryDigest(query string, rs *resultset.ResultSet) string {
+	orderBy := compare.ParseOrderBy(query)
+	if orderBy == nil || strings.Contains(strings.ToLower(query), "force-unordered") {
+		return unorderedDigest(rs, nil)
+	}
+	header := make([]string, rs.NCols())
+	for i := range header {
+		header[i] = rs.ColumnDef(i).Name
+	}
+	keys := orderBy.KeyColumns(header)
+	if len(keys) == 0 {
+		return rs.DataDigest()
+	}
+	return partialOrderDigest(rs, keys, orderBy.Limit, orderBy.Offset)
+}
+
+// partialOrderDigest digests groups of rows with tied sort keys in order, and rows
+// in a group unordered, only the keys and size of the last group are digested if
+// the rows are cut by the LIMIT of limit rows (negative if there is not one), since
+// it can be any rows with the tied keys, and so is the first group if there is an offset
+func partialOrderDigest(rs *resultset.ResultSet, keys []int, limit int, offset bool) string {
+	limited := limit >= 0 && rs.NRows() == limit
+	cols := make([]int, rs.NCols())
+	for i := range cols {
+		cols[i] = i
+	}
+	h := sha1.New()
+	for i := 0; i < rs.NRows(); {
+		key := make([][]byte, len(keys))
+		for k, j := range keys {
+			key[k], _ = rs.RawValue(i, j)
+		}
+		group := make(rows, 0)
+		for ; i < rs.NRows(); i++ {
+			same := true
+			for k, j := range keys {
+				raw, _ := rs.RawValue(i, j)
+				same = same && (raw == nil) == (key[k] == nil) && bytes.Equal(raw, key[k])
+			}
+			if !same {
+				break
+			}
+			group = append(group, rowDigest(rs, i, cols))
+		}
+
+		h.Write([]byte(compare.EncodeRow(key)))
+		fmt.Fprintf(h, "#%d", len(group))
+		if (limited && i == rs.NRows()) || (offset && i == len(group)) {
+			continue
+		}
+		sort.Sort(group)
+		for _, digest := range group {
+			h.Write(digest)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func rowDigest(rs *resultset.ResultSet, i int, cols []int) []byte {
+	h := sha1.New()
+	for _, j := range cols {
+		raw, _ := rs.RawValue(i, j)
+		if rs.ColumnDef(j).Type == "JSON" {
+			raw = normalizeJSON(raw)
+		}
+		h.Write(raw)
+	}
+	return h.Sum(nil)
+}
+
 func unorderedDigest(rs *resultset.ResultSet, colFilter func(resultset.ColumnDef) bool) string {
 	if colFilter == nil {
 		colFilter = func(_ resultset.ColumnDef) bool { return true }
@@ -300,12 +448,7 @@ func unorderedDigest(rs *resultset.ResultSet, colFilter func(resultset.ColumnDef
 	}
 	digests := make(rows, rs.NRows())
 	for i := 0; i < rs.NRows(); i++ {
-		h := sha1.New()
-		for _, j := range cols {
-			raw, _ := rs.RawValue(i, j)
-			h.Write(raw)
-		}
-		digests[i] = h.Sum(nil)
+		digests[i] = rowDigest(rs, i, cols)
 	}
 	sort.Sort(digests)
 	h := sha1.New()
@@ -315,6 +458,27 @@ func unorderedDigest(rs *resultset.ResultSet, colFilter func(resultset.ColumnDef
 	return hex.EncodeToString(h.Sum(nil))
 }
 
+// normalizeJSON re-encodes a json document with sorted keys and compact
+// spaces, so that equal documents formatted differently have the same digest
+func normalizeJSON(raw []byte) []byte {
+	if raw == nil {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return raw
+	}
+	buf := new(bytes.Buffer)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(doc); err != nil {
+		return raw
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
 func doStmt(ctx context.Context, tx *sql.Tx, stmt Stmt) (*resultset.ResultSet, error) {
 	if stmt.IsQuery {
 		rows, err := tx.QueryContext(ctx, stmt.Stmt)
@@ -331,7 +495,3 @@ func doStmt(ctx context.Context, tx *sql.Tx, stmt Stmt) (*resultset.ResultSet, e
 		return resultset.NewFromResult(res), nil
 	}
 }
-
-func validateErrs(err1 error, err2 error) bool {
-	return (err1 == nil && err2 == nil) || (err1 != nil && err2 != nil)
-}
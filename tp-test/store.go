@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/pingcap/go-randgen/compare"
 	"github.com/zyguan/sqlz"
 
 	. "github.com/zyguan/just"
@@ -19,6 +20,7 @@ const (
 	TestRunning = "Running"
 	TestFailed  = "Failed"
 	TestPassed  = "Passed"
+	TestTimeout = "Timeout"
 	TestUnknown = "Unknown"
 )
 
@@ -27,10 +29,13 @@ type Result struct {
 	Err          error
 	RowsAffected int64
 	LastInsertId int64
+	Warnings     []*compare.Warning
 }
 
 type Store interface {
 	Init() error
+	// Migrate adds the columns of newer versions to an initialized store
+	Migrate() error
 	Clear() error
 	AddTest(test Test) error
 	NextPendingTest() (*Test, error)
@@ -72,6 +77,8 @@ type store struct {
 
 func (s *store) Init() error { return initDB(s.db) }
 
+func (s *store) Migrate() error { return migrateDB(s.db) }
+
 func (s *store) Clear() error { return clearDB(s.db) }
 
 func (s *store) AddTest(test Test) (err error) {
@@ -156,8 +163,12 @@ func (s *store) PutStmtResult(id string, seq int, tag string, result Result) err
 	if result.Err != nil {
 		errmsg = result.Err.Error()
 	}
-	_, err := s.db.Exec("insert into stmt_result (test_id, seq, tag, errmsg, result, rows_affected, last_insert_id, created_at) values (?, ?, ?, ?, ?, ?, ?, ?)",
-		id, seq, tag, errmsg, result.Raw, result.RowsAffected, result.LastInsertId, time.Now().Unix())
+	var warnings []byte
+	if result.Warnings != nil {
+		warnings, _ = json.Marshal(result.Warnings)
+	}
+	_, err := s.db.Exec("insert into stmt_result (test_id, seq, tag, errmsg, result, rows_affected, last_insert_id, warnings, created_at) values (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		id, seq, tag, errmsg, result.Raw, result.RowsAffected, result.LastInsertId, warnings, time.Now().Unix())
 	return err
 }
 
@@ -191,6 +202,7 @@ func initDB(db *sqlz.DB) (err error) {
     result longblob,
     rows_affected int,
     last_insert_id int,
+    warnings text,
     created_at int not null,
     primary key (id),
     key (test_id, seq)
@@ -199,6 +211,20 @@ func initDB(db *sqlz.DB) (err error) {
 	return nil
 }
 
+func migrateDB(db *sqlz.DB) error {
+	// warnings of stmt_result is added by --warnings
+	var cnt int
+	if err := db.QueryRow(`select count(*) from information_schema.columns
+    where table_schema = database() and table_name = 'stmt_result' and column_name = 'warnings'`).Scan(&cnt); err != nil {
+		return err
+	}
+	if cnt > 0 {
+		return nil
+	}
+	_, err := db.Exec("alter table stmt_result add column warnings text")
+	return err
+}
+
 func clearDB(db *sqlz.DB) error {
 	_, err := db.Exec("drop table if exists test, stmt, stmt_result")
 	return err